@@ -0,0 +1,277 @@
+package grequest
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AdaptiveRateLimit configures a per host rate which is lowered when the
+// server pushes back and raised again while requests succeed (AIMD)
+type AdaptiveRateLimit struct {
+	// Requests per second a host starts with, 10 by default
+	InitialRate float64
+	// Lowest rate, 0.5 by default
+	MinRate float64
+	// Highest rate, 10 times InitialRate by default. A RateLimit-Policy
+	// header of the host lowers it further.
+	MaxRate float64
+	// Rate grows by about Increase every second of successful requests, 1 by default
+	Increase float64
+	// Rate is multiplied by Decrease when the server pushes back, 0.5 by default
+	Decrease float64
+	// Status codes which mean the server is overloaded, 429 and 503 by default
+	StatusCodes []int
+}
+
+// rateLimitInfo is the state of a server side quota announced in headers
+type rateLimitInfo struct {
+	// Requests left in the window, -1 when unknown
+	remaining int
+	// Time until the quota is reset
+	reset time.Duration
+	// Quota and window of the policy, 0 when unknown
+	quota  float64
+	window time.Duration
+}
+
+// adaptiveLimits are the adaptive rates of the hosts of a client
+type adaptiveLimits struct {
+	mu     sync.Mutex
+	config AdaptiveRateLimit
+	hosts  map[string]*adaptiveHost
+}
+
+// adaptiveHost is the current rate of one host
+type adaptiveHost struct {
+	mu      sync.Mutex
+	rate    float64
+	maxRate float64
+	limiter *RateLimiter
+}
+
+func (a AdaptiveRateLimit) withDefaults() AdaptiveRateLimit {
+	if a.InitialRate <= 0 {
+		a.InitialRate = 10
+	}
+	if a.MinRate <= 0 {
+		a.MinRate = 0.5
+	}
+	if a.MaxRate <= 0 {
+		a.MaxRate = a.InitialRate * 10
+	}
+	if a.Increase <= 0 {
+		a.Increase = 1
+	}
+	if a.Decrease <= 0 || a.Decrease >= 1 {
+		a.Decrease = 0.5
+	}
+	if a.StatusCodes == nil {
+		a.StatusCodes = []int{http.StatusTooManyRequests, http.StatusServiceUnavailable}
+	}
+	a.StatusCodes = slices.Clone(a.StatusCodes)
+	return a
+}
+
+func (a *adaptiveLimits) host(host string) *adaptiveHost {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	h, ok := a.hosts[host]
+	if !ok {
+		rate := min(max(a.config.InitialRate, a.config.MinRate), a.config.MaxRate)
+		h = &adaptiveHost{rate: rate, maxRate: a.config.MaxRate, limiter: NewRateLimiter(rate, 1)}
+		a.hosts[host] = h
+	}
+	return h
+}
+
+// Updates the rate of the host with the response of an attempt
+func (a *adaptiveLimits) observe(host string, res *http.Response) {
+	if res == nil {
+		return
+	}
+	h := a.host(host)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	info, ok := parseRateLimit(res.Header)
+	if ok && info.quota > 0 && info.window > 0 {
+		h.maxRate = max(min(a.config.MaxRate, info.quota/info.window.Seconds()), a.config.MinRate)
+	}
+	var pause time.Duration
+	throttled := slices.Contains(a.config.StatusCodes, res.StatusCode)
+	if throttled {
+		pause, _ = retryAfter(res)
+	}
+	if ok && info.remaining == 0 {
+		throttled = true
+		pause = max(pause, info.reset)
+	}
+	if throttled {
+		h.rate = max(h.rate*a.config.Decrease, a.config.MinRate)
+	} else if res.StatusCode < 500 {
+		h.rate += a.config.Increase / h.rate
+	}
+	h.rate = min(h.rate, h.maxRate)
+	h.limiter.SetLimit(h.rate, 1)
+	if pause > 0 {
+		h.limiter.bucket.pause(pause)
+	}
+}
+
+// Gets the current rate of the host
+func (h *adaptiveHost) currentRate() float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rate
+}
+
+// Makes the next reservation wait at least d
+func (b *tokenBucket) pause(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens = min(b.tokens, -d.Seconds()*b.rate)
+}
+
+// Parses the IETF RateLimit and RateLimit-Policy headers, their older
+// RateLimit-Limit/Remaining/Reset form and the X-RateLimit-* and
+// X-Rate-Limit-* variants of vendors
+func parseRateLimit(header http.Header) (rateLimitInfo, bool) {
+	info := rateLimitInfo{remaining: -1}
+	found := false
+	// RateLimit: "default";r=0;t=30 or RateLimit: limit=100, remaining=0, reset=30
+	for _, item := range headerItems(header.Values("RateLimit")) {
+		if value, ok := item["r"]; ok {
+			found = info.setRemaining(value) || found
+		}
+		if value, ok := item["remaining"]; ok {
+			found = info.setRemaining(value) || found
+		}
+		if value, ok := item["t"]; ok {
+			info.setReset(value)
+		}
+		if value, ok := item["reset"]; ok {
+			info.setReset(value)
+		}
+	}
+	// RateLimit-Policy: "default";q=100;w=60 or RateLimit-Policy: 100;w=60
+	for _, item := range headerItems(header.Values("RateLimit-Policy")) {
+		quota, err := strconv.ParseFloat(item["q"], 64)
+		if err != nil {
+			quota, err = strconv.ParseFloat(item[""], 64)
+		}
+		window, windowErr := strconv.ParseFloat(item["w"], 64)
+		if err != nil || windowErr != nil || quota <= 0 || window <= 0 {
+			continue
+		}
+		rate := quota / window
+		if info.quota == 0 || rate < info.quota/info.window.Seconds() {
+			info.quota = quota
+			info.window = time.Duration(window * float64(time.Second))
+		}
+		found = true
+	}
+	for _, prefix := range []string{"RateLimit-", "X-RateLimit-", "X-Rate-Limit-"} {
+		if value := header.Get(prefix + "Remaining"); value != "" {
+			found = info.setRemaining(value) || found
+		}
+		if value := header.Get(prefix + "Reset-After"); value != "" {
+			info.setReset(value)
+		} else if value := header.Get(prefix + "Reset"); value != "" {
+			info.setReset(value)
+		}
+	}
+	return info, found
+}
+
+// Splits structured header values into items of parameters, a bare value
+// of an item is stored under an empty key
+func headerItems(values []string) []map[string]string {
+	var items []map[string]string
+	for _, line := range values {
+		for _, part := range strings.Split(line, ",") {
+			item := map[string]string{}
+			for _, param := range strings.Split(part, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok {
+					key, value = "", key
+				}
+				item[strings.ToLower(strings.TrimSpace(key))] = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+			items = append(items, item)
+		}
+	}
+	// The older form puts each parameter in its own item, they belong together
+	if len(items) > 1 {
+		merged := map[string]string{}
+		for _, item := range items {
+			for key, value := range item {
+				if key != "" {
+					merged[key] = value
+				}
+			}
+		}
+		if _, ok := merged["remaining"]; ok {
+			return []map[string]string{merged}
+		}
+	}
+	return items
+}
+
+// Keeps the lowest remaining count of all quotas
+func (i *rateLimitInfo) setRemaining(value string) bool {
+	remaining, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || remaining < 0 {
+		return false
+	}
+	if i.remaining < 0 || remaining < i.remaining {
+		i.remaining = remaining
+	}
+	return true
+}
+
+// Keeps the longest reset, given in seconds or as a unix time
+func (i *rateLimitInfo) setReset(value string) {
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || seconds < 0 {
+		return
+	}
+	reset := time.Duration(seconds * float64(time.Second))
+	// Values like 1700000000 are a point in time and not a delay
+	if seconds > 1e9 {
+		reset = time.Until(time.Unix(int64(seconds), 0))
+	}
+	i.reset = max(i.reset, reset)
+}
+
+// Enables adaptive rate limiting of every host, requests wait for the
+// current rate of their host which follows the responses of the server
+func (c *Client) SetAdaptiveRateLimit(config AdaptiveRateLimit) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adaptive = &adaptiveLimits{config: config.withDefaults(), hosts: make(map[string]*adaptiveHost)}
+	return c
+}
+
+// Disables adaptive rate limiting for new requests
+func (c *Client) DisableAdaptiveRateLimit() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.adaptive = nil
+	return c
+}
+
+// Gets the current adaptive rate of the host in requests per second,
+// 0 when adaptive rate limiting is disabled
+func (c *Client) AdaptiveRate(host string) float64 {
+	c.mu.RLock()
+	adaptive := c.adaptive
+	c.mu.RUnlock()
+	if adaptive == nil {
+		return 0
+	}
+	return adaptive.host(host).currentRate()
+}
@@ -0,0 +1,117 @@
+package grequest
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   rateLimitInfo
+		ok     bool
+	}{
+		{
+			"structured",
+			map[string]string{"RateLimit": `"default";r=0;t=30`, "RateLimit-Policy": `"default";q=100;w=60`},
+			rateLimitInfo{remaining: 0, reset: 30 * time.Second, quota: 100, window: time.Minute},
+			true,
+		},
+		{
+			"several quotas",
+			map[string]string{"RateLimit": `"burst";r=5;t=1, "day";r=900;t=3600`},
+			rateLimitInfo{remaining: 5, reset: time.Hour},
+			true,
+		},
+		{
+			"dictionary",
+			map[string]string{"RateLimit": "limit=100, remaining=3, reset=10", "RateLimit-Policy": "100;w=10"},
+			rateLimitInfo{remaining: 3, reset: 10 * time.Second, quota: 100, window: 10 * time.Second},
+			true,
+		},
+		{
+			"separate fields",
+			map[string]string{"RateLimit-Limit": "100", "RateLimit-Remaining": "7", "RateLimit-Reset": "5"},
+			rateLimitInfo{remaining: 7, reset: 5 * time.Second},
+			true,
+		},
+		{
+			"vendor",
+			map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset-After": "2.5", "X-RateLimit-Reset": "99"},
+			rateLimitInfo{remaining: 0, reset: 2500 * time.Millisecond},
+			true,
+		},
+		{
+			"twitter",
+			map[string]string{"X-Rate-Limit-Remaining": "12"},
+			rateLimitInfo{remaining: 12},
+			true,
+		},
+		{
+			"invalid",
+			map[string]string{"X-RateLimit-Remaining": "many"},
+			rateLimitInfo{remaining: -1},
+			false,
+		},
+		{"none", map[string]string{}, rateLimitInfo{remaining: -1}, false},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		for key, value := range tt.header {
+			header.Set(key, value)
+		}
+		got, ok := parseRateLimit(header)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: parseRateLimit = %+v, %v, want %+v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseRateLimitResetAsUnixTime(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10))
+	info, ok := parseRateLimit(header)
+	if !ok || info.reset < 58*time.Second || info.reset > time.Minute {
+		t.Fatalf("reset = %s, %v", info.reset, ok)
+	}
+}
+
+func TestAdaptiveRateFollowsResponses(t *testing.T) {
+	limits := &adaptiveLimits{
+		config: AdaptiveRateLimit{InitialRate: 10, MaxRate: 12}.withDefaults(),
+		hosts:  make(map[string]*adaptiveHost),
+	}
+	ok := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+
+	limits.observe("h", throttled)
+	if rate := limits.host("h").currentRate(); rate != 5 {
+		t.Fatalf("rate after 429 = %v, want 5", rate)
+	}
+	for range 100 {
+		limits.observe("h", ok)
+	}
+	if rate := limits.host("h").currentRate(); rate != 12 {
+		t.Fatalf("rate after successes = %v, want the max 12", rate)
+	}
+
+	// A policy of 60 requests a minute caps the rate at 1 per second
+	policy := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	policy.Header.Set("RateLimit-Policy", `"default";q=60;w=60`)
+	limits.observe("h", policy)
+	if rate := limits.host("h").currentRate(); rate != 1 {
+		t.Fatalf("rate with policy = %v, want 1", rate)
+	}
+
+	// No requests left lowers the rate although the status is fine
+	exhausted := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	exhausted.Header.Set("X-RateLimit-Remaining", "0")
+	limits.observe("other", exhausted)
+	if rate := limits.host("other").currentRate(); rate != 5 {
+		t.Fatalf("rate when exhausted = %v, want 5", rate)
+	}
+}
@@ -22,17 +22,25 @@ func (c *Auth) SetBasic(user, pass string) *HTTPClient {
 		User: user,
 		Pass: pass,
 	}
+	c.Client.request.header.Del(authorization)
 	return c.Client
 }
 
 // Sets custom auth token in header request
 func (c *Auth) SetToken(token string) *HTTPClient {
+	c.Client.request.basic = nil
 	c.Client.Header().Set(authorization, token)
 	return c.Client
 }
 
 // Sets bearer token in header request
 func (c *Auth) SetBearer(token string) *HTTPClient {
-	c.Client.Header().Set(authorization, "Bearer "+token)
+	return c.SetToken("Bearer " + token)
+}
+
+// Removes auth of the request, also the one inherited from the client
+func (c *Auth) Remove() *HTTPClient {
+	c.Client.request.basic = nil
+	c.Client.Header().Del(authorization)
 	return c.Client
 }
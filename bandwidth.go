@@ -0,0 +1,165 @@
+package grequest
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+const (
+	minBandwidthChunk = 512
+	maxBandwidthChunk = 64 << 10
+)
+
+// BandwidthLimiter caps the speed of bodies read through it. One limiter may
+// be shared by any number of concurrent requests and clients, the cap then
+// applies to all of them together.
+type BandwidthLimiter struct {
+	bucket *tokenBucket
+}
+
+// throttledReader reads through a chain of bandwidth limiters
+type throttledReader struct {
+	io.Reader
+	ctx      context.Context
+	limiters []*BandwidthLimiter
+}
+
+// throttledReadCloser is a throttledReader of a request body
+type throttledReadCloser struct {
+	throttledReader
+	closer io.Closer
+}
+
+// Init limiter of bytesPerSecond
+func NewBandwidthLimiter(bytesPerSecond int64) *BandwidthLimiter {
+	rate := float64(max(bytesPerSecond, 1))
+	return &BandwidthLimiter{bucket: newTokenBucket(rate, bandwidthBurst(rate))}
+}
+
+// Sets the cap in bytes per second, affects transfers already running
+func (l *BandwidthLimiter) SetLimit(bytesPerSecond int64) *BandwidthLimiter {
+	rate := float64(max(bytesPerSecond, 1))
+	l.bucket.setRate(rate, bandwidthBurst(rate))
+	return l
+}
+
+// Gets the cap in bytes per second
+func (l *BandwidthLimiter) Limit() int64 {
+	l.bucket.mu.Lock()
+	defer l.bucket.mu.Unlock()
+	return int64(l.bucket.rate)
+}
+
+// Gets the biggest read done at once, about a tenth of a second of transfer
+func bandwidthBurst(rate float64) float64 {
+	return min(max(rate/10, minBandwidthChunk), maxBandwidthChunk)
+}
+
+func (l *BandwidthLimiter) chunk() int {
+	l.bucket.mu.Lock()
+	defer l.bucket.mu.Unlock()
+	return int(l.bucket.burst)
+}
+
+// Takes n bytes from the bucket and waits until they are allowed
+func (l *BandwidthLimiter) wait(ctx context.Context, n int) error {
+	return sleepContext(ctx, l.bucket.reserve(float64(n)))
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	for _, l := range r.limiters {
+		p = p[:min(len(p), l.chunk())]
+	}
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		for _, l := range r.limiters {
+			if waitErr := l.wait(r.ctx, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+	}
+	return n, err
+}
+
+func (r *throttledReadCloser) Close() error {
+	return r.closer.Close()
+}
+
+// Gets limiters of the request, the client one first
+func (c *HTTPClient) bandwidthLimiters() []*BandwidthLimiter {
+	var limiters []*BandwidthLimiter
+	if c.clientBandwidth != nil {
+		limiters = append(limiters, c.clientBandwidth)
+	}
+	if c.bandwidth != nil && c.bandwidth != c.clientBandwidth {
+		limiters = append(limiters, c.bandwidth)
+	}
+	return limiters
+}
+
+// Wraps the request body so it is sent no faster than the bandwidth limits
+func (c *HTTPClient) throttleUpload(req *http.Request) {
+	limiters := c.bandwidthLimiters()
+	if len(limiters) == 0 || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	ctx := req.Context()
+	wrap := func(body io.ReadCloser) io.ReadCloser {
+		return &throttledReadCloser{
+			throttledReader: throttledReader{Reader: body, ctx: ctx, limiters: limiters},
+			closer:          body,
+		}
+	}
+	req.Body = wrap(req.Body)
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return wrap(body), nil
+		}
+	}
+}
+
+// Wraps the response body so it is read no faster than the bandwidth limits
+func (c *HTTPClient) throttleDownload(ctx context.Context, body io.Reader) io.Reader {
+	limiters := c.bandwidthLimiters()
+	if len(limiters) == 0 {
+		return body
+	}
+	return &throttledReader{Reader: body, ctx: ctx, limiters: limiters}
+}
+
+// Sets bandwidth cap in bytes per second shared by all requests of the client,
+// 0 removes it
+func (c *Client) SetBandwidth(bytesPerSecond int64) *Client {
+	if bytesPerSecond <= 0 {
+		return c.SetBandwidthLimiter(nil)
+	}
+	return c.SetBandwidthLimiter(NewBandwidthLimiter(bytesPerSecond))
+}
+
+// Sets bandwidth limiter shared by all requests of the client, nil removes it
+func (c *Client) SetBandwidthLimiter(limiter *BandwidthLimiter) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bandwidth = limiter
+	return c
+}
+
+// Sets bandwidth cap in bytes per second of this request, 0 removes it
+// The client cap still applies, the lower one wins
+func (c *HTTPClient) SetBandwidth(bytesPerSecond int64) *HTTPClient {
+	if bytesPerSecond <= 0 {
+		return c.SetBandwidthLimiter(nil)
+	}
+	return c.SetBandwidthLimiter(NewBandwidthLimiter(bytesPerSecond))
+}
+
+// Sets bandwidth limiter of this request, it may be shared with other requests
+func (c *HTTPClient) SetBandwidthLimiter(limiter *BandwidthLimiter) *HTTPClient {
+	c.bandwidth = limiter
+	return c
+}
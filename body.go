@@ -3,14 +3,32 @@ package grequest
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"strings"
+	"net/http"
+	"path/filepath"
+	"sync"
 )
 
 type Body struct {
-	savePath string
-	Client   *HTTPClient
+	Client *HTTPClient
+}
+
+// requestBody produces a fresh reader of the request payload for every send,
+// so retries and redirects resend the same content
+type requestBody struct {
+	open       func() (io.ReadCloser, error)
+	length     int64
+	rewindable bool
+}
+
+type ResponseBody struct {
+	savePath  string
+	digests   []Digest
+	collision Collision
+	savedFile string
+	Response  *Response
 }
 
 const filesPath = "files"
@@ -25,103 +43,300 @@ func (c *Body) client() *HTTPClient {
 	return c.Client
 }
 
-func (c *Body) loadPath() string {
-	toPath := filesPath
-	if c.savePath != "" {
-		return c.savePath
-	}
-	currentHost := c.client().GetCurrentUrl()
-	path := fmt.Sprintf("%s/%s", toPath, currentHost.Host)
-	return path
-}
-
-// Gets raw io.ReadCloser from body
-func (c *Body) get() io.Reader {
-	return io.NopCloser(bytes.NewReader(c.client().BodyBytes))
-}
-
-func (c *Body) getBytes() []byte {
-	return c.client().BodyBytes
-}
-
 // Sets the request body with io.Reader
+// Seekable readers are resent on retries and redirects, any other reader can be sent once
 func (c *Body) Set(body io.Reader) *HTTPClient {
-	c.client().request.body = body
+	if body == nil {
+		c.client().request.body = nil
+		return c.client()
+	}
+	reqBody, err := newReaderBody(body)
+	if err != nil {
+		c.client().addError(PhaseBuild, err)
+		return c.client()
+	}
+	c.client().request.body = reqBody
 	return c.client()
 }
 
 // Sets the request body with only string
 func (c *Body) SetString(body string) *HTTPClient {
-	c.client().request.body = strings.NewReader(body)
+	c.client().request.body = newBytesBody([]byte(body))
 	return c.client()
 }
 
 // Sets the request body with bytes
 func (c *Body) SetByte(body []byte) *HTTPClient {
-	c.client().request.body = bytes.NewBuffer(body)
+	c.client().request.body = newBytesBody(body)
 	return c.client()
 }
 
 // Sets the request body with json
 func (c *Body) SetJson(data interface{}) *HTTPClient {
-	jsonData, _ := json.Marshal(data)
-	c.client().request.body = bytes.NewBuffer(jsonData)
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		c.client().addError(PhaseBuild, err)
+		return c.client()
+	}
+	c.client().request.body = newBytesBody(jsonData)
 	return c.client()
 }
 
-// Gets raw io.ReadCloser from body
-func (c *Body) GetRaw() io.Reader {
+// Gets body of the reader, seekable readers and buffers can be sent many times
+func newReaderBody(body io.Reader) (*requestBody, error) {
+	switch b := body.(type) {
+	case *bytes.Buffer:
+		return newBytesBody(b.Bytes()), nil
+	case io.ReadSeeker:
+		return newSeekerBody(b)
+	}
+	return newStreamBody(body), nil
+}
+
+func newBytesBody(data []byte) *requestBody {
+	return &requestBody{
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(data)), nil
+		},
+		length:     int64(len(data)),
+		rewindable: true,
+	}
+}
+
+// Gets body of the seeker from its current offset. Every send reads its own section
+// of a reader with ReadAt, so a send does not move the offset of another one still read
+// by the transport. Other seekers are read to memory once.
+func newSeekerBody(body io.ReadSeeker) (*requestBody, error) {
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	readerAt, ok := body.(io.ReaderAt)
+	if !ok {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		return newBytesBody(data), nil
+	}
+	end, err := body.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := body.Seek(start, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return &requestBody{
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(io.NewSectionReader(readerAt, start, end-start)), nil
+		},
+		length:     end - start,
+		rewindable: true,
+	}, nil
+}
+
+func newStreamBody(body io.Reader) *requestBody {
+	var mu sync.Mutex
+	used := false
+	return &requestBody{
+		open: func() (io.ReadCloser, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if used {
+				return nil, ErrBodyNotRewindable
+			}
+			used = true
+			if rc, ok := body.(io.ReadCloser); ok {
+				return rc, nil
+			}
+			return io.NopCloser(body), nil
+		},
+		length: -1,
+	}
+}
+
+// Sets body of the http request and GetBody when the body can be replayed
+func (b *requestBody) apply(req *http.Request) error {
+	if b.length == 0 {
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) { return http.NoBody, nil }
+		return nil
+	}
+	rc, err := b.open()
+	if err != nil {
+		return err
+	}
+	req.Body = rc
+	req.ContentLength = b.length
+	if b.rewindable {
+		req.GetBody = b.open
+	}
+	return nil
+}
+
+// Init response body
+func (r *Response) Body() *ResponseBody {
+	return &ResponseBody{Response: r}
+}
+
+// Gets http response object
+func (c *ResponseBody) response() *Response {
+	return c.Response
+}
+
+func (c *ResponseBody) loadPath() string {
+	toPath := filesPath
+	if c.savePath != "" {
+		return c.savePath
+	}
+	currentHost := c.response().GetCurrentUrl()
+	path := fmt.Sprintf("%s/%s", toPath, currentHost.Host)
+	return path
+}
+
+// Gets reader of the body, a streamed body is handed out only once
+func (c *ResponseBody) get() io.Reader {
+	r := c.response()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stream != nil && !r.stream.consumed {
+		r.stream.consumed = true
+		return r.stream
+	}
+	return bytes.NewReader(r.BodyBytes)
+}
+
+func (c *ResponseBody) getBytes() []byte {
+	r := c.response()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stream != nil && !r.stream.consumed {
+		r.stream.consumed = true
+		body, err := io.ReadAll(r.stream)
+		if err != nil {
+			r.addError(PhaseRead, err)
+		}
+		r.BodyBytes = body
+		r.closeStream()
+	}
+	return r.BodyBytes
+}
+
+// Gets raw io.Reader from body
+func (c *ResponseBody) GetRaw() io.Reader {
 	return c.get()
 }
 
+// Gets body as io.ReadCloser, closing it closes the response
+func (c *ResponseBody) Reader() io.ReadCloser {
+	return &streamBody{Reader: c.get(), Closer: c.response()}
+}
+
+// Writes response body to w, a streamed body is copied straight from the network
+func (c *ResponseBody) WriteTo(w io.Writer) (int64, error) {
+	defer c.response().Close()
+	n, err := io.Copy(w, c.get())
+	if err != nil {
+		return n, c.response().addError(PhaseRead, err)
+	}
+	return n, nil
+}
+
 // Gets response body and return in bytes
-func (c *Body) GetBytes() []byte {
+func (c *ResponseBody) GetBytes() []byte {
 	b := c.getBytes()
 	return b
 }
 
 // Gets response body and return as string
-func (c *Body) GetStrings() string {
+func (c *ResponseBody) GetStrings() string {
 	b := c.getBytes()
 	return string(b)
 }
 
 // Gets response body and return as interface map array
-func (c *Body) GetWithJson() (interface{}, error) {
+func (c *ResponseBody) GetWithJson() (interface{}, error) {
 	content := c.getBytes()
 	var WithJson interface{}
 	err := json.Unmarshal(content, &WithJson)
-	return WithJson, err
+	if err != nil {
+		return WithJson, c.response().newError(PhaseDecode, err)
+	}
+	return WithJson, nil
 }
 
 // Gets response body and make to struct
-func (c *Body) GetWithJsonStruct(target interface{}) error {
+func (c *ResponseBody) GetWithJsonStruct(target interface{}) error {
 	b := c.getBytes()
 
 	err := json.Unmarshal(b, &target)
 	if err != nil {
-		return err
+		return c.response().newError(PhaseDecode, err)
 	}
 	return nil
 }
 
 // Sets path for save
-func (c *Body) Path(path string) *Body {
+func (c *ResponseBody) Path(path string) *ResponseBody {
 	c.savePath = path
 	return c
 }
 
 // Save response body to file
-func (c *Body) ToFile(fileName string) {
-	saveToFile(fileName, c.get())
+func (c *ResponseBody) ToFile(fileName string) error {
+	defer c.response().Close()
+	return c.save(fileName)
+}
+
+// Writes the body to the file and verifies it against expected and announced digests
+func (c *ResponseBody) save(fileName string) error {
+	err := saveToFile(fileName, c.get(), c.digests, responseDigests(c.response().raw))
+	if errors.Is(err, ErrChecksumMismatch) {
+		return c.response().addError(PhaseVerify, err)
+	}
+	if err != nil {
+		return c.response().addError(PhaseSave, err)
+	}
+	return nil
+}
+
+// Sets what SaveFile does when the file already exists, overwrite by default
+func (c *ResponseBody) OnCollision(collision Collision) *ResponseBody {
+	c.collision = collision
+	return c
+}
+
+// Gets file name for SaveFile, taken from Content-Disposition or the url path
+// with an extension matching the content type
+func (c *ResponseBody) FileName() string {
+	name := getFileNameByContentDisposition(c.response().Header().Get(contentDisposition))
+	if name == "" {
+		name = getFileNameByPath(c.response().GetCurrentUrl().Path)
+	}
+	name = sanitizeFileName(name)
+	if name == "" {
+		name = defaultFileName
+	}
+	if filepath.Ext(name) == "" {
+		name += getFileExtensionByContentType(c.response().ContentType())
+	}
+	return name
+}
+
+// Gets path of the file written by SaveFile, empty when nothing was saved
+func (c *ResponseBody) SavedFile() string {
+	return c.savedFile
 }
 
 // Save response body to file
-func (c *Body) SaveFile() *Body {
-	extension := getFileExtensionByContentType(c.client().ContentType().Get())
-	currentHost := c.client().GetCurrentUrl()
-	name := getFileNameByPath(currentHost.Path)
-	fileName := fmt.Sprintf("%s/%s%s", c.loadPath(), name, extension)
-	saveToFile(fileName, c.get())
+func (c *ResponseBody) SaveFile() *ResponseBody {
+	defer c.response().Close()
+	fileName, ok := resolveCollision(filepath.Join(c.loadPath(), c.FileName()), c.collision)
+	if !ok {
+		return c
+	}
+	if c.save(fileName) == nil {
+		c.savedFile = fileName
+	}
 	return c
 }
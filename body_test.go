@@ -0,0 +1,42 @@
+package grequest
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// onlySeeker hides ReadAt of the reader
+type onlySeeker struct {
+	io.ReadSeeker
+}
+
+func TestSeekerBodyOpensAreIndependent(t *testing.T) {
+	for name, reader := range map[string]io.ReadSeeker{
+		"ReaderAt": strings.NewReader("skip:payload"),
+		"Seeker":   onlySeeker{strings.NewReader("skip:payload")},
+	} {
+		if _, err := reader.Seek(5, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		body, err := newReaderBody(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !body.rewindable || body.length != 7 {
+			t.Fatalf("%s: rewindable = %v, length = %d", name, body.rewindable, body.length)
+		}
+		// A resend must not move the reader of an attempt still being sent
+		first, _ := body.open()
+		buf := make([]byte, 3)
+		if _, err := io.ReadFull(first, buf); err != nil {
+			t.Fatal(err)
+		}
+		second, _ := body.open()
+		rest, _ := io.ReadAll(first)
+		again, _ := io.ReadAll(second)
+		if got := string(buf) + string(rest); got != "payload" || string(again) != "payload" {
+			t.Errorf("%s: first = %q, second = %q, want payload", name, got, again)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package grequest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httputil"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cacheControlField    = "Cache-Control"
+	etagField            = "ETag"
+	lastModifiedField    = "Last-Modified"
+	ifNoneMatchField     = "If-None-Match"
+	ifModifiedSinceField = "If-Modified-Since"
+)
+
+// Max count of stored variants of one url selected by Vary
+const maxCacheVariants = 8
+
+// Status codes which may be stored, RFC 9110 section 15.1
+var cacheableStatusCodes = []int{200, 203, 204, 300, 301, 308, 404, 405, 410, 414, 501}
+
+// Response headers which are not taken from a 304 when updating a stored response
+var cacheKeptHeaders = []string{"Content-Length", "Content-Encoding", "Transfer-Encoding"}
+
+// cacheEntry is a stored response together with what is needed to select and age it
+type cacheEntry struct {
+	Stored   time.Time         `json:"stored"`
+	Vary     map[string]string `json:"vary"`
+	Response []byte            `json:"response"`
+}
+
+// cacheControl holds parsed Cache-Control directives
+type cacheControl map[string]string
+
+func parseCacheControl(header http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, line := range header.Values(cacheControlField) {
+		for _, part := range strings.Split(line, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if name == "" {
+				continue
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	value, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0, true
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+func cacheKey(req *http.Request) string {
+	return http.MethodGet + " " + req.URL.String()
+}
+
+// Gets how long a stored response stays fresh
+func cacheLifetime(res *http.Response, stored time.Time, cc cacheControl) time.Duration {
+	if maxAge, ok := cc.seconds("max-age"); ok {
+		return maxAge
+	}
+	expires := res.Header.Get("Expires")
+	if expires == "" {
+		return 0
+	}
+	expiresAt, err := http.ParseTime(expires)
+	if err != nil {
+		return 0
+	}
+	date, err := http.ParseTime(res.Header.Get("Date"))
+	if err != nil {
+		date = stored
+	}
+	return expiresAt.Sub(date)
+}
+
+// Gets current age of a stored response
+func cacheAge(res *http.Response, stored time.Time) time.Duration {
+	age := time.Since(stored)
+	if seconds, err := strconv.Atoi(res.Header.Get("Age")); err == nil && seconds > 0 {
+		age += time.Duration(seconds) * time.Second
+	}
+	return age
+}
+
+func isCacheable(req *http.Request, res *http.Response) bool {
+	if req.Method != http.MethodGet || !slices.Contains(cacheableStatusCodes, res.StatusCode) {
+		return false
+	}
+	if parseCacheControl(req.Header).has("no-store") {
+		return false
+	}
+	cc := parseCacheControl(res.Header)
+	if cc.has("no-store") {
+		return false
+	}
+	if strings.TrimSpace(res.Header.Get("Vary")) == "*" {
+		return false
+	}
+	return cc.has("max-age") ||
+		res.Header.Get("Expires") != "" ||
+		res.Header.Get(etagField) != "" ||
+		res.Header.Get(lastModifiedField) != ""
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// Gets values of the request headers listed in Vary
+func varyValues(req *http.Request, header http.Header) map[string]string {
+	values := map[string]string{}
+	for _, line := range header.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name != "" {
+				values[name] = req.Header.Get(name)
+			}
+		}
+	}
+	return values
+}
+
+// Gets a new copy of the stored response
+func (e *cacheEntry) response(req *http.Request) (*http.Response, error) {
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(e.Response)), req)
+}
+
+func (e *cacheEntry) matches(req *http.Request) bool {
+	for name, value := range e.Vary {
+		if req.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *HTTPClient) loadCacheEntries(req *http.Request) []cacheEntry {
+	data, ok := c.cache.Get(cacheKey(req))
+	if !ok {
+		return nil
+	}
+	var entries []cacheEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+// Gets the stored variant which was selected by the same request headers
+func (c *HTTPClient) loadCache(req *http.Request) *cacheEntry {
+	for _, entry := range c.loadCacheEntries(req) {
+		if entry.matches(req) {
+			return &entry
+		}
+	}
+	return nil
+}
+
+// Stores the response, its body is replaced by an in-memory copy
+func (c *HTTPClient) storeCache(req *http.Request, res *http.Response) {
+	dump, err := httputil.DumpResponse(res, true)
+	if err != nil {
+		return
+	}
+	entry := cacheEntry{
+		Stored:   time.Now(),
+		Vary:     varyValues(req, res.Header),
+		Response: dump,
+	}
+	entries := slices.DeleteFunc(c.loadCacheEntries(req), func(stored cacheEntry) bool {
+		return maps.Equal(stored.Vary, entry.Vary)
+	})
+	entries = append([]cacheEntry{entry}, entries...)
+	if len(entries) > maxCacheVariants {
+		entries = entries[:maxCacheVariants]
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	c.cache.Set(cacheKey(req), data)
+}
+
+// Updates the stored response with headers of a 304 and stores it again
+func (c *HTTPClient) refreshCache(req *http.Request, cached, notModified *http.Response) {
+	for key, values := range notModified.Header {
+		if !slices.Contains(cacheKeptHeaders, key) {
+			cached.Header[key] = values
+		}
+	}
+	c.storeCache(req, cached)
+}
+
+// Gets a copy of the request which asks the server to validate the stored response
+func conditionalRequest(req *http.Request, cached *http.Response) *http.Request {
+	next := req.Clone(req.Context())
+	if etag := cached.Header.Get(etagField); etag != "" {
+		next.Header.Set(ifNoneMatchField, etag)
+	}
+	if lastModified := cached.Header.Get(lastModifiedField); lastModified != "" {
+		next.Header.Set(ifModifiedSinceField, lastModified)
+	}
+	return next
+}
+
+// Sends the request through the http cache when it is enabled
+func (c *HTTPClient) fetch(httpClient *http.Client, req *http.Request, response *Response) (*http.Response, error) {
+	if c.cache == nil {
+		return c.send(httpClient, req, response)
+	}
+	if req.Method != http.MethodGet {
+		res, err := c.send(httpClient, req, response)
+		if err == nil && !isSafeMethod(req.Method) && res.StatusCode < 400 {
+			c.cache.Delete(cacheKey(req))
+		}
+		return res, err
+	}
+	reqCC := parseCacheControl(req.Header)
+	// A stored full response can not answer a range request and partial
+	// responses are not stored
+	if reqCC.has("no-store") || req.Header.Get(rangeField) != "" {
+		return c.send(httpClient, req, response)
+	}
+
+	entry := c.loadCache(req)
+	var cached *http.Response
+	if entry != nil {
+		var err error
+		if cached, err = entry.response(req); err != nil {
+			entry = nil
+		}
+	}
+	sendReq := req
+	if cached != nil {
+		resCC := parseCacheControl(cached.Header)
+		age := cacheAge(cached, entry.Stored)
+		lifetime := cacheLifetime(cached, entry.Stored, resCC)
+		revalidate := resCC.has("no-cache") || reqCC.has("no-cache")
+		if maxAge, ok := reqCC.seconds("max-age"); ok && age > maxAge {
+			revalidate = true
+		}
+		if !revalidate && age < lifetime {
+			response.fromCache = true
+			return cached, nil
+		}
+		if staleWindow, ok := resCC.seconds("stale-while-revalidate"); ok && !revalidate &&
+			!resCC.has("must-revalidate") && age < lifetime+staleWindow {
+			go c.revalidateCache(httpClient, req, entry)
+			response.fromCache = true
+			return cached, nil
+		}
+		sendReq = conditionalRequest(req, cached)
+	}
+
+	res, err := c.send(httpClient, sendReq, response)
+	if err != nil {
+		return res, err
+	}
+	if cached != nil && res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		c.refreshCache(req, cached, res)
+		response.fromCache = true
+		return cached, nil
+	}
+	if !c.stream && isCacheable(req, res) {
+		c.storeCache(req, res)
+	}
+	return res, nil
+}
+
+// Validates a stale stored response in background
+func (c *HTTPClient) revalidateCache(httpClient *http.Client, req *http.Request, entry *cacheEntry) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), timeoutDefault)
+	defer cancel()
+	cached, err := entry.response(req)
+	if err != nil {
+		return
+	}
+	res, err := c.handler(httpClient)(conditionalRequest(req.WithContext(ctx), cached))
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotModified {
+		c.refreshCache(req, cached, res)
+		return
+	}
+	if isCacheable(req, res) {
+		c.storeCache(req, res)
+	}
+}
+
+// Enables the http cache with the given storage, nil disables it
+func (c *Client) SetCache(storage CacheStorage) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = storage
+	c.cacheEnabled = storage != nil
+	return c
+}
+
+// Sends the request bypassing the http cache
+func (c *HTTPClient) DisableCache() *HTTPClient {
+	c.cache = nil
+	return c
+}
@@ -0,0 +1,209 @@
+package grequest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	header := http.Header{}
+	header.Add(cacheControlField, `max-age=60, No-Cache`)
+	header.Add(cacheControlField, `private="Set-Cookie", stale-while-revalidate=x`)
+	cc := parseCacheControl(header)
+	if maxAge, ok := cc.seconds("max-age"); !ok || maxAge != time.Minute {
+		t.Errorf("max-age = %s, %v", maxAge, ok)
+	}
+	if !cc.has("no-cache") || cc["private"] != "Set-Cookie" {
+		t.Errorf("directives = %v", cc)
+	}
+	// An invalid value counts as zero
+	if stale, ok := cc.seconds("stale-while-revalidate"); !ok || stale != 0 {
+		t.Errorf("stale-while-revalidate = %s, %v", stale, ok)
+	}
+	if _, ok := cc.seconds("s-maxage"); ok {
+		t.Error("s-maxage found")
+	}
+}
+
+func TestCacheLifetime(t *testing.T) {
+	date := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+	}{
+		{"max-age", map[string]string{cacheControlField: "max-age=30", "Expires": date.Add(time.Hour).Format(http.TimeFormat)}, 30 * time.Second},
+		{"expires", map[string]string{"Date": date.Format(http.TimeFormat), "Expires": date.Add(time.Hour).Format(http.TimeFormat)}, time.Hour},
+		{"invalid expires", map[string]string{"Expires": "0"}, 0},
+		{"none", map[string]string{}, 0},
+	}
+	for _, tt := range tests {
+		res := &http.Response{Header: http.Header{}}
+		for key, value := range tt.header {
+			res.Header.Set(key, value)
+		}
+		if got := cacheLifetime(res, date, parseCacheControl(res.Header)); got != tt.want {
+			t.Errorf("%s: lifetime = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsCacheable(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		status int
+		header map[string]string
+		want   bool
+	}{
+		{"max-age", http.MethodGet, 200, map[string]string{cacheControlField: "max-age=60"}, true},
+		{"etag", http.MethodGet, 200, map[string]string{etagField: `"a"`}, true},
+		{"no validator", http.MethodGet, 200, map[string]string{}, false},
+		{"no-store", http.MethodGet, 200, map[string]string{cacheControlField: "no-store, max-age=60"}, false},
+		{"vary star", http.MethodGet, 200, map[string]string{cacheControlField: "max-age=60", "Vary": "*"}, false},
+		{"post", http.MethodPost, 200, map[string]string{cacheControlField: "max-age=60"}, false},
+		{"server error", http.MethodGet, 500, map[string]string{cacheControlField: "max-age=60"}, false},
+		{"not found", http.MethodGet, 404, map[string]string{cacheControlField: "max-age=60"}, true},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "http://h/", nil)
+		res := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		for key, value := range tt.header {
+			res.Header.Set(key, value)
+		}
+		if got := isCacheable(req, res); got != tt.want {
+			t.Errorf("%s: cacheable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", []byte("1"))
+	cache.Set("b", []byte("2"))
+	cache.Get("a")
+	cache.Set("c", []byte("3"))
+	if _, ok := cache.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+	if value, ok := cache.Get("a"); !ok || string(value) != "1" {
+		t.Errorf("a = %q, %v", value, ok)
+	}
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("a was not deleted")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	cache := NewDiskCache(t.TempDir())
+	cache.Set("GET http://h/", []byte("stored"))
+	if value, ok := cache.Get("GET http://h/"); !ok || string(value) != "stored" {
+		t.Fatalf("value = %q, %v", value, ok)
+	}
+	cache.Delete("GET http://h/")
+	if _, ok := cache.Get("GET http://h/"); ok {
+		t.Fatal("entry was not deleted")
+	}
+}
+
+func TestCacheServesFreshResponse(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(cacheControlField, "max-age=60")
+		fmt.Fprintf(w, "hit %d", hits.Add(1))
+	}))
+	defer srv.Close()
+	client := NewClient().SetCache(NewMemoryCache(10))
+
+	first := client.Get(srv.URL).Do()
+	second := client.Get(srv.URL).Do()
+	if hits.Load() != 1 || first.FromCache() || !second.FromCache() {
+		t.Fatalf("hits = %d, from cache = %v, %v", hits.Load(), first.FromCache(), second.FromCache())
+	}
+	if body := second.Body().GetStrings(); body != "hit 1" {
+		t.Fatalf("body = %q", body)
+	}
+	// An unsafe method invalidates the stored response
+	client.Post(srv.URL).Do()
+	if res := client.Get(srv.URL).Do(); res.FromCache() {
+		t.Fatal("response after POST came from the cache")
+	}
+}
+
+func TestCacheRevalidatesAndMergesNotModified(t *testing.T) {
+	var hits, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := hits.Add(1)
+		w.Header().Set(cacheControlField, "no-cache")
+		w.Header().Set(etagField, `"v1"`)
+		w.Header().Set("X-Hit", fmt.Sprint(n))
+		if r.Header.Get(ifNoneMatchField) == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(w, "content")
+	}))
+	defer srv.Close()
+	client := NewClient().SetCache(NewMemoryCache(10))
+
+	client.Get(srv.URL).Do()
+	res := client.Get(srv.URL).Do()
+	if notModified.Load() != 1 || !res.FromCache() {
+		t.Fatalf("304 responses = %d, from cache = %v", notModified.Load(), res.FromCache())
+	}
+	if body := res.Body().GetStrings(); body != "content" {
+		t.Fatalf("body = %q", body)
+	}
+	// Headers of the 304 replace the stored ones, the length of the body is kept
+	if got := res.Raw().Header.Get("X-Hit"); got != "2" {
+		t.Fatalf("X-Hit = %q, want 2", got)
+	}
+	if res.Raw().ContentLength != int64(len("content")) {
+		t.Fatalf("Content-Length = %d", res.Raw().ContentLength)
+	}
+}
+
+func TestCacheKeepsVariantsSelectedByVary(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set(cacheControlField, "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprint(w, r.Header.Get("Accept-Language"))
+	}))
+	defer srv.Close()
+	client := NewClient().SetCache(NewMemoryCache(10))
+	get := func(lang string) *Response {
+		return client.Get(srv.URL).Header().Set("Accept-Language", lang).Do()
+	}
+
+	get("en")
+	get("de")
+	en, de := get("en"), get("de")
+	if hits.Load() != 2 {
+		t.Fatalf("hits = %d, want 2", hits.Load())
+	}
+	if !en.FromCache() || en.Body().GetStrings() != "en" || !de.FromCache() || de.Body().GetStrings() != "de" {
+		t.Fatal("variants were not served from the cache")
+	}
+}
+
+func TestCacheSkipsNoStore(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set(cacheControlField, "no-store, max-age=60")
+	}))
+	defer srv.Close()
+	client := NewClient().SetCache(NewMemoryCache(10))
+	client.Get(srv.URL).Do()
+	if res := client.Get(srv.URL).Do(); res.FromCache() || hits.Load() != 2 {
+		t.Fatalf("hits = %d, from cache = %v", hits.Load(), res.FromCache())
+	}
+}
@@ -0,0 +1,248 @@
+package grequest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Digest is an expected checksum of a saved file
+type Digest struct {
+	Algorithm string
+	Sum       []byte
+}
+
+const (
+	contentMD5Field = "Content-MD5"
+	digestField     = "Digest"
+	reprDigestField = "Repr-Digest"
+)
+
+var digestAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// Gets algorithm name without dashes and case, like sha256 for SHA-256
+func normalizeAlgorithm(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(name), "-", ""))
+	if name == "sha" {
+		return "sha1"
+	}
+	return name
+}
+
+// Init digest from algorithm name (md5, sha1, sha256, sha384, sha512) and hex encoded sum
+func NewDigest(algorithm, hexSum string) (Digest, error) {
+	algorithm = normalizeAlgorithm(algorithm)
+	newHash, ok := digestAlgorithms[algorithm]
+	if !ok {
+		return Digest{}, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	sum, err := hex.DecodeString(strings.TrimSpace(hexSum))
+	if err != nil {
+		return Digest{}, err
+	}
+	if len(sum) != newHash().Size() {
+		return Digest{}, fmt.Errorf("invalid %s sum length %d", algorithm, len(sum))
+	}
+	return Digest{Algorithm: algorithm, Sum: sum}, nil
+}
+
+// Parses Subresource Integrity metadata like "sha384-base64sum"
+func ParseIntegrity(integrity string) ([]Digest, error) {
+	var digests []Digest
+	for _, item := range strings.Fields(integrity) {
+		item, _, _ = strings.Cut(item, "?")
+		algorithm, value, found := strings.Cut(item, "-")
+		if !found {
+			return nil, fmt.Errorf("invalid integrity %q", item)
+		}
+		digest, err := newBase64Digest(algorithm, value)
+		if err != nil {
+			return nil, err
+		}
+		digests = append(digests, digest)
+	}
+	if len(digests) == 0 {
+		return nil, fmt.Errorf("invalid integrity %q", integrity)
+	}
+	return digests, nil
+}
+
+func newBase64Digest(algorithm, value string) (Digest, error) {
+	algorithm = normalizeAlgorithm(algorithm)
+	if _, ok := digestAlgorithms[algorithm]; !ok {
+		return Digest{}, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+	sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return Digest{}, err
+	}
+	return Digest{Algorithm: algorithm, Sum: sum}, nil
+}
+
+// Gets digests announced by Content-MD5, Digest and Repr-Digest headers.
+// Digests of an encoded or partial body are skipped as they do not describe the file.
+func responseDigests(res *http.Response) []Digest {
+	if res == nil || res.Uncompressed || res.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	var digests []Digest
+	if res.StatusCode == http.StatusOK {
+		if value := res.Header.Get(contentMD5Field); value != "" {
+			if digest, err := newBase64Digest("md5", value); err == nil {
+				digests = append(digests, digest)
+			}
+		}
+	}
+	for _, line := range res.Header.Values(digestField) {
+		for _, item := range strings.Split(line, ",") {
+			algorithm, value, found := strings.Cut(strings.TrimSpace(item), "=")
+			if !found {
+				continue
+			}
+			if digest, err := newBase64Digest(algorithm, value); err == nil {
+				digests = append(digests, digest)
+			}
+		}
+	}
+	for _, line := range res.Header.Values(reprDigestField) {
+		for _, item := range strings.Split(line, ",") {
+			algorithm, value, found := strings.Cut(strings.TrimSpace(item), "=")
+			if !found {
+				continue
+			}
+			if digest, err := newBase64Digest(algorithm, strings.Trim(value, ":")); err == nil {
+				digests = append(digests, digest)
+			}
+		}
+	}
+	return digests
+}
+
+// digestWriter hashes written data with every algorithm of the expected digests
+type digestWriter struct {
+	hashes map[string]hash.Hash
+}
+
+func newDigestWriter(digests []Digest) *digestWriter {
+	w := &digestWriter{hashes: map[string]hash.Hash{}}
+	for _, digest := range digests {
+		if _, ok := w.hashes[digest.Algorithm]; !ok {
+			w.hashes[digest.Algorithm] = digestAlgorithms[digest.Algorithm]()
+		}
+	}
+	return w
+}
+
+func (w *digestWriter) Write(p []byte) (int, error) {
+	for _, h := range w.hashes {
+		h.Write(p)
+	}
+	return len(p), nil
+}
+
+// Checks every set of digests on its own, a set matches when for every
+// algorithm at least one of its sums matches
+func (w *digestWriter) verify(digestSets ...[]Digest) error {
+	for _, digests := range digestSets {
+		if err := w.verifySet(digests); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *digestWriter) verifySet(digests []Digest) error {
+	matched := map[string]bool{}
+	for _, digest := range digests {
+		if bytes.Equal(w.hashes[digest.Algorithm].Sum(nil), digest.Sum) {
+			matched[digest.Algorithm] = true
+		} else if !matched[digest.Algorithm] {
+			matched[digest.Algorithm] = false
+		}
+	}
+	for algorithm, ok := range matched {
+		if !ok {
+			return fmt.Errorf("%w: %s is %x", ErrChecksumMismatch, algorithm, w.hashes[algorithm].Sum(nil))
+		}
+	}
+	return nil
+}
+
+// Checks the file content against every set of digests
+func verifyFile(fileName string, digestSets ...[]Digest) error {
+	digests := slices.Concat(digestSets...)
+	if len(digests) == 0 {
+		return nil
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := newDigestWriter(digests)
+	if _, err := io.Copy(w, file); err != nil {
+		return err
+	}
+	return w.verify(digestSets...)
+}
+
+// Sets expected checksum of the downloaded file, algorithm is md5, sha1, sha256, sha384 or sha512
+func (d *Download) Checksum(algorithm, hexSum string) *Download {
+	digest, err := NewDigest(algorithm, hexSum)
+	if err != nil {
+		d.client().addError(PhaseBuild, err)
+		return d
+	}
+	d.digests = append(d.digests, digest)
+	return d
+}
+
+// Sets expected Subresource Integrity of the downloaded file like "sha256-base64sum"
+func (d *Download) Integrity(integrity string) *Download {
+	digests, err := ParseIntegrity(integrity)
+	if err != nil {
+		d.client().addError(PhaseBuild, err)
+		return d
+	}
+	d.digests = append(d.digests, digests...)
+	return d
+}
+
+// Sets expected checksum of the saved file, algorithm is md5, sha1, sha256, sha384 or sha512
+func (c *ResponseBody) Checksum(algorithm, hexSum string) *ResponseBody {
+	digest, err := NewDigest(algorithm, hexSum)
+	if err != nil {
+		c.response().addError(PhaseBuild, err)
+		return c
+	}
+	c.digests = append(c.digests, digest)
+	return c
+}
+
+// Sets expected Subresource Integrity of the saved file like "sha256-base64sum"
+func (c *ResponseBody) Integrity(integrity string) *ResponseBody {
+	digests, err := ParseIntegrity(integrity)
+	if err != nil {
+		c.response().addError(PhaseBuild, err)
+		return c
+	}
+	c.digests = append(c.digests, digests...)
+	return c
+}
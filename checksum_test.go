@@ -0,0 +1,97 @@
+package grequest
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var checksumContent = []byte("checksum content")
+
+func TestNewDigest(t *testing.T) {
+	sum := sha256.Sum256(checksumContent)
+	digest, err := NewDigest("SHA-256", hex.EncodeToString(sum[:]))
+	if err != nil || digest.Algorithm != "sha256" {
+		t.Fatalf("digest = %+v, %v", digest, err)
+	}
+	for _, tt := range []struct{ algorithm, sum string }{
+		{"crc32", "00"},
+		{"sha256", "zz"},
+		{"sha256", "00ff"},
+	} {
+		if _, err := NewDigest(tt.algorithm, tt.sum); err == nil {
+			t.Errorf("NewDigest(%q, %q) succeeded", tt.algorithm, tt.sum)
+		}
+	}
+}
+
+func TestParseIntegrity(t *testing.T) {
+	sum := sha512.Sum384(checksumContent)
+	value := base64.StdEncoding.EncodeToString(sum[:])
+	digests, err := ParseIntegrity("sha384-" + value + "?opt sha512-" + value)
+	if err != nil || len(digests) != 2 || digests[0].Algorithm != "sha384" || digests[1].Algorithm != "sha512" {
+		t.Fatalf("digests = %+v, %v", digests, err)
+	}
+	for _, integrity := range []string{"", "sha384", "md4-AAAA", "sha256-!!"} {
+		if _, err := ParseIntegrity(integrity); err == nil {
+			t.Errorf("ParseIntegrity(%q) succeeded", integrity)
+		}
+	}
+}
+
+func TestResponseDigests(t *testing.T) {
+	md5Sum := md5.Sum(checksumContent)
+	shaSum := sha256.Sum256(checksumContent)
+	md5Value := base64.StdEncoding.EncodeToString(md5Sum[:])
+	shaValue := base64.StdEncoding.EncodeToString(shaSum[:])
+	res := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	res.Header.Set(contentMD5Field, md5Value)
+	res.Header.Set(digestField, "SHA-256="+shaValue+", unknown=abc")
+	res.Header.Set(reprDigestField, "sha-256=:"+shaValue+":")
+	digests := responseDigests(res)
+	if len(digests) != 3 {
+		t.Fatalf("digests = %+v, want 3", digests)
+	}
+	for i, algorithm := range []string{"md5", "sha256", "sha256"} {
+		if digests[i].Algorithm != algorithm {
+			t.Errorf("digest %d is %s, want %s", i, digests[i].Algorithm, algorithm)
+		}
+	}
+
+	// Content-MD5 of a partial response does not describe the file
+	res.StatusCode = http.StatusPartialContent
+	if got := responseDigests(res); len(got) != 2 {
+		t.Errorf("partial response digests = %+v, want 2", got)
+	}
+	res.Header.Set("Content-Encoding", "gzip")
+	if got := responseDigests(res); got != nil {
+		t.Errorf("encoded response digests = %+v, want none", got)
+	}
+}
+
+func TestVerifyFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(fileName, checksumContent, 0666); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(checksumContent)
+	good := Digest{Algorithm: "sha256", Sum: sum[:]}
+	bad := Digest{Algorithm: "sha256", Sum: make([]byte, len(sum))}
+	if err := verifyFile(fileName, []Digest{good}); err != nil {
+		t.Fatal(err)
+	}
+	// One matching sum of an algorithm is enough within a set
+	if err := verifyFile(fileName, []Digest{bad, good}); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyFile(fileName, []Digest{good}, []Digest{bad}); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("err = %v, want checksum mismatch", err)
+	}
+}
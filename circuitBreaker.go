@@ -0,0 +1,271 @@
+package grequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker
+type CircuitState int
+
+const (
+	// Requests are sent and their outcomes counted
+	CircuitClosed CircuitState = iota
+	// Requests fail at once with a CircuitOpenError
+	CircuitOpen
+	// A few trial requests are sent to decide whether to close again
+	CircuitHalfOpen
+)
+
+const (
+	circuitOpenTimeoutDefault = 30 * time.Second
+	circuitWindowDefault      = time.Minute
+)
+
+// CircuitBreakerPolicy describes when a circuit opens and how it recovers
+type CircuitBreakerPolicy struct {
+	// Opens after this many failures in a row, 0 disables it
+	ConsecutiveFailures int
+	// Opens when this share of requests in the window failed, 0 disables it
+	FailureRatio float64
+	// Least requests in the window before the ratio is checked, 10 by default
+	MinRequests int
+	// Length of the window the ratio is counted over, 1 minute by default
+	Window time.Duration
+	// How long the circuit stays open before trial requests, 30 seconds by default
+	OpenTimeout time.Duration
+	// Trial requests which must succeed to close the circuit again, 1 by default
+	HalfOpenRequests int
+	// Status codes counted as failures, 500, 502, 503 and 504 by default.
+	// Network errors always count as failures.
+	StatusCodes []int
+	// Gets circuit key of a request, the host by default
+	Key func(req *http.Request) string
+}
+
+// CircuitOpenError is returned for a request whose circuit is open
+type CircuitOpenError struct {
+	Key   string
+	State CircuitState
+	// Time until trial requests are allowed, 0 when the trials are running
+	RetryAfter time.Duration
+}
+
+// circuitBreakers are the circuits of a client by their keys
+type circuitBreakers struct {
+	mu       sync.Mutex
+	policy   CircuitBreakerPolicy
+	circuits map[string]*circuit
+}
+
+// circuit is the state of one key, guarded by the mutex of its breakers
+type circuit struct {
+	state     CircuitState
+	openedAt  time.Time
+	inARow    int
+	requests  int
+	failures  int
+	windowEnd time.Time
+	// Trial requests sent and succeeded while half open
+	trials    int
+	succeeded int
+}
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+func (e *CircuitOpenError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v: %q, trial in %s", ErrCircuitOpen, e.Key, e.RetryAfter.Round(time.Millisecond))
+	}
+	return fmt.Sprintf("%v: %q is %s", ErrCircuitOpen, e.Key, e.State)
+}
+
+func (e *CircuitOpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
+
+func (p CircuitBreakerPolicy) withDefaults() CircuitBreakerPolicy {
+	if p.ConsecutiveFailures <= 0 && p.FailureRatio <= 0 {
+		p.ConsecutiveFailures = 5
+	}
+	if p.MinRequests <= 0 {
+		p.MinRequests = 10
+	}
+	if p.Window <= 0 {
+		p.Window = circuitWindowDefault
+	}
+	if p.OpenTimeout <= 0 {
+		p.OpenTimeout = circuitOpenTimeoutDefault
+	}
+	if p.HalfOpenRequests <= 0 {
+		p.HalfOpenRequests = 1
+	}
+	if p.StatusCodes == nil {
+		p.StatusCodes = []int{
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+	p.StatusCodes = slices.Clone(p.StatusCodes)
+	return p
+}
+
+func (b *circuitBreakers) key(req *http.Request) string {
+	if b.policy.Key != nil {
+		return b.policy.Key(req)
+	}
+	return req.URL.Host
+}
+
+func (b *circuitBreakers) circuit(key string) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+	return c
+}
+
+// Gets whether a request with the key may be sent, an allowed request
+// must report its outcome to the returned func, nil and nil when not sent
+func (b *circuitBreakers) allow(key string) (func(res *http.Response, err error), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(key)
+	now := time.Now()
+	if c.state == CircuitOpen {
+		if wait := c.openedAt.Add(b.policy.OpenTimeout).Sub(now); wait > 0 {
+			return nil, &CircuitOpenError{Key: key, State: c.state, RetryAfter: wait}
+		}
+		c.state = CircuitHalfOpen
+		c.trials, c.succeeded = 0, 0
+	}
+	half := c.state == CircuitHalfOpen
+	if half {
+		if c.trials >= b.policy.HalfOpenRequests {
+			return nil, &CircuitOpenError{Key: key, State: c.state}
+		}
+		c.trials++
+	}
+	opened := c.openedAt
+	return func(res *http.Response, err error) {
+		b.record(key, opened, half, b.isFailure(res, err))
+	}, nil
+}
+
+// Gets whether the outcome counts as a failure, nil when it does not count
+// at all like a request canceled by the caller
+func (b *circuitBreakers) isFailure(res *http.Response, err error) *bool {
+	var failed bool
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		return nil
+	case err != nil:
+		failed = true
+	case res != nil:
+		failed = slices.Contains(b.policy.StatusCodes, res.StatusCode)
+	default:
+		return nil
+	}
+	return &failed
+}
+
+func (b *circuitBreakers) record(key string, opened time.Time, half bool, failed *bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.circuit(key)
+	// The circuit changed since the request was allowed
+	if !c.openedAt.Equal(opened) || half != (c.state == CircuitHalfOpen) {
+		return
+	}
+	if half {
+		switch {
+		case failed == nil:
+			c.trials--
+		case *failed:
+			c.open(time.Now())
+		default:
+			c.succeeded++
+			if c.succeeded >= b.policy.HalfOpenRequests {
+				*c = circuit{}
+			}
+		}
+		return
+	}
+	if failed == nil {
+		return
+	}
+	now := time.Now()
+	if now.After(c.windowEnd) {
+		c.requests, c.failures = 0, 0
+		c.windowEnd = now.Add(b.policy.Window)
+	}
+	c.requests++
+	if !*failed {
+		c.inARow = 0
+		return
+	}
+	c.failures++
+	c.inARow++
+	p := b.policy
+	if (p.ConsecutiveFailures > 0 && c.inARow >= p.ConsecutiveFailures) ||
+		(p.FailureRatio > 0 && c.requests >= p.MinRequests && float64(c.failures)/float64(c.requests) >= p.FailureRatio) {
+		c.open(now)
+	}
+}
+
+func (c *circuit) open(now time.Time) {
+	*c = circuit{state: CircuitOpen, openedAt: now}
+}
+
+// Enables a circuit breaker for new requests, every host or key of the
+// policy gets its own circuit
+func (c *Client) SetCircuitBreaker(policy CircuitBreakerPolicy) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.breakers = &circuitBreakers{policy: policy.withDefaults(), circuits: make(map[string]*circuit)}
+	return c
+}
+
+// Disables the circuit breaker for new requests
+func (c *Client) DisableCircuitBreaker() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.breakers = nil
+	return c
+}
+
+// Gets state of the circuit of the host or key
+func (c *Client) CircuitState(key string) CircuitState {
+	c.mu.RLock()
+	breakers := c.breakers
+	c.mu.RUnlock()
+	if breakers == nil {
+		return CircuitClosed
+	}
+	breakers.mu.Lock()
+	defer breakers.mu.Unlock()
+	circuit, ok := breakers.circuits[key]
+	if !ok {
+		return CircuitClosed
+	}
+	if circuit.state == CircuitOpen && time.Since(circuit.openedAt) >= breakers.policy.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return circuit.state
+}
@@ -0,0 +1,98 @@
+package grequest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndRecovers(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	host := srv.Listener.Addr().String()
+	client := NewClient().SetCircuitBreaker(CircuitBreakerPolicy{ConsecutiveFailures: 3, OpenTimeout: 100 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		if err := client.Get(srv.URL).Do().Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if state := client.CircuitState(host); state != CircuitOpen {
+		t.Fatalf("state = %s, want open", state)
+	}
+	res := client.Get(srv.URL).Do()
+	var openErr *CircuitOpenError
+	if !errors.As(res.Err(), &openErr) || !errors.Is(res.Err(), ErrCircuitOpen) {
+		t.Fatalf("err = %v, want circuit open", res.Err())
+	}
+	if openErr.Key != host || openErr.RetryAfter <= 0 {
+		t.Fatalf("error = %+v", openErr)
+	}
+	if n := hits.Load(); n != 3 {
+		t.Fatalf("server hits = %d, want 3", n)
+	}
+
+	// A failed trial opens the circuit again
+	time.Sleep(150 * time.Millisecond)
+	if state := client.CircuitState(host); state != CircuitHalfOpen {
+		t.Fatalf("state = %s, want half-open", state)
+	}
+	client.Get(srv.URL).Do()
+	if state := client.CircuitState(host); state != CircuitOpen {
+		t.Fatalf("state = %s, want open", state)
+	}
+
+	time.Sleep(150 * time.Millisecond)
+	down.Store(false)
+	if err := client.Get(srv.URL).Do().Err(); err != nil {
+		t.Fatal(err)
+	}
+	if state := client.CircuitState(host); state != CircuitClosed {
+		t.Fatalf("state = %s, want closed", state)
+	}
+}
+
+func TestCircuitBreakerFailureRatio(t *testing.T) {
+	breakers := &circuitBreakers{
+		policy:   CircuitBreakerPolicy{FailureRatio: 0.5, MinRequests: 4}.withDefaults(),
+		circuits: make(map[string]*circuit),
+	}
+	ok := &http.Response{StatusCode: http.StatusOK}
+	failed := &http.Response{StatusCode: http.StatusBadGateway}
+	for _, res := range []*http.Response{ok, failed, ok, failed} {
+		done, err := breakers.allow("h")
+		if err != nil {
+			t.Fatal(err)
+		}
+		done(res, nil)
+	}
+	if _, err := breakers.allow("h"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want circuit open", err)
+	}
+}
+
+func TestCircuitOpenClosesBody(t *testing.T) {
+	client := NewClient().SetCircuitBreaker(CircuitBreakerPolicy{ConsecutiveFailures: 1, OpenTimeout: time.Minute})
+	// Nothing listens on the port, the failed dial opens the circuit
+	client.Get("http://127.0.0.1:1/").Do()
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		res := multipartRequest(client).Do()
+		if !errors.Is(res.Err(), ErrCircuitOpen) {
+			t.Fatalf("err = %v, want circuit open", res.Err())
+		}
+	}
+	waitGoroutines(t, before)
+}
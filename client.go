@@ -0,0 +1,198 @@
+package grequest
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Client owns the transport, cookie jar and defaults shared by every request
+// it creates. A Client is safe for concurrent use and should be reused so
+// connections and cookies are pooled between calls.
+type Client struct {
+	mu             sync.RWMutex
+	cacheEnabled   bool
+	cache          CacheStorage
+	baseURL        *url.URL
+	maxRedirect    int
+	redirectPolicy RedirectPolicy
+	retry          RetryPolicy
+	timeout        time.Duration
+	userAgent      string
+	header         http.Header
+	cookies        []*http.Cookie
+	basic          *BasicAuth
+	query          url.Values
+	bandwidth      *BandwidthLimiter
+	middlewares    []Middleware
+	hooks          hooks
+	rateLimits     *rateLimits
+	adaptive       *adaptiveLimits
+	breakers       *circuitBreakers
+	client         *http.Client
+	transport      *http.Transport
+	cjar           http.CookieJar
+	errs           []error
+}
+
+// Init shared client with default transport and cookie jar
+func NewClient() *Client {
+	c := &Client{
+		transport: &http.Transport{
+			MaxIdleConns:        100,
+			MaxIdleConnsPerHost: 32,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: true,
+			},
+		},
+		maxRedirect: maxRedirectDefault,
+		retry:       defaultRetryPolicy(),
+		userAgent:   userAgentName,
+		header:      make(http.Header),
+		query:       url.Values{},
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{})
+	if err != nil {
+		c.errs = append(c.errs, &RequestError{Phase: PhaseBuild, Err: err})
+	}
+	c.cjar = jar
+	c.client = c.newHTTPClient()
+	return c
+}
+
+func (c *Client) newHTTPClient() *http.Client {
+	return &http.Client{
+		Jar:       c.cjar,
+		Transport: c.transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Gets the underlying http client
+func (c *Client) httpClient() *http.Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.client
+}
+
+// Creates a new independent request builder which inherits the client defaults
+func (c *Client) NewRequest() *HTTPClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	header := c.header.Clone()
+	if header.Get(userAgentField) == "" {
+		header.Set(userAgentField, c.userAgent)
+	}
+	var basic *BasicAuth
+	if c.basic != nil {
+		auth := *c.basic
+		basic = &auth
+	}
+	var cache CacheStorage
+	if c.cacheEnabled {
+		cache = c.cache
+	}
+	return &HTTPClient{
+		cache:           cache,
+		baseURL:         c.baseURL,
+		client:          c,
+		maxRedirect:     c.maxRedirect,
+		redirectPolicy:  c.redirectPolicy,
+		retry:           c.retry.clone(),
+		Timeout:         c.timeout,
+		clientBandwidth: c.bandwidth,
+		middlewares:     slices.Clone(c.middlewares),
+		hooks:           c.hooks.clone(),
+		rateLimits:      c.rateLimits,
+		adaptive:        c.adaptive,
+		breakers:        c.breakers,
+		request: &Request{
+			method: http.MethodGet,
+			header: header,
+			basic:  basic,
+			query:  c.defaultQuery(),
+			Cookie: slices.Clone(c.cookies),
+		},
+		errs: slices.Clone(c.errs),
+	}
+}
+
+// Sets default user agent for new requests
+func (c *Client) SetUserAgent(agent string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.userAgent = agent
+	return c
+}
+
+// Sets default timeout for new requests
+func (c *Client) SetTimeout(timeout time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = timeout
+	return c
+}
+
+// Sets default max redirect count for new requests
+// 0 = disable redirects
+func (c *Client) MaxRedirect(maxRedirect int) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxRedirect = maxRedirect
+	return c
+}
+
+// Sets tls config of the shared transport
+func (c *Client) SetTLSConfig(config *tls.Config) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.transport = c.transport.Clone()
+	c.transport.TLSClientConfig = config
+	c.client = c.newHTTPClient()
+	return c
+}
+
+// Sets proxy of the shared transport
+func (c *Client) SetProxy(uri string) *Client {
+	u, err := checkURL(uri)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.errs = append(c.errs, &RequestError{Phase: PhaseBuild, Err: err})
+		return c
+	}
+	c.transport = c.transport.Clone()
+	c.transport.Proxy = http.ProxyURL(u)
+	c.client = c.newHTTPClient()
+	return c
+}
+
+// Sets cookie jar shared by all requests
+func (c *Client) SetCookieJar(jar http.CookieJar) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cjar = jar
+	c.client = c.newHTTPClient()
+	return c
+}
+
+// Gets all errors collected while configuring the client joined into one
+func (c *Client) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return errors.Join(c.errs...)
+}
+
+// Gets cookie jar shared by all requests
+func (c *Client) CookieJar() http.CookieJar {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cjar
+}
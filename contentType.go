@@ -36,8 +36,3 @@ func (c *ContentType) SetMultipartFormData() *HTTPClient {
 	c.client().Header().Set(contentType, multipartFormData)
 	return c.client()
 }
-
-// Gets content type fron response headers and return as string
-func (c *ContentType) Get() string {
-	return c.client().Header().Get(contentType)
-}
@@ -3,18 +3,26 @@ package grequest
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"net/http/cookiejar"
 	"os"
+	"slices"
 )
 
 type Cookie struct {
 	savePath string
-	cookies  *http.Cookie
 	Client   *HTTPClient
 }
+
+type ResponseCookie struct {
+	savePath string
+	Response *Response
+}
+
 type SerializableCookies struct {
 	URL     string         `json:"url"`
 	Cookies []*http.Cookie `json:"cookies"`
@@ -33,13 +41,14 @@ func (c *Cookie) client() *HTTPClient {
 }
 
 func (c *Cookie) loadPath() string {
-	toPath := cookiesPath
 	if c.savePath != "" {
 		return c.savePath
 	}
-	currentHost := c.client().GetCurrentUrl()
-	fromFilePath := fmt.Sprintf("%s/%s/cookies.json", toPath, currentHost.Host)
-	return fromFilePath
+	return cookiesFilePath(c.client().GetCurrentUrl().Host)
+}
+
+func cookiesFilePath(host string) string {
+	return fmt.Sprintf("%s/%s/cookies.json", cookiesPath, host)
 }
 
 // Sets cookies as string ex: name=xxxx; count=x
@@ -54,15 +63,18 @@ func (c *Cookie) SetCookies(cookies []*http.Cookie) *HTTPClient {
 	return c.Client
 }
 
-func (c *Cookie) SetCookieJar(jar *cookiejar.Jar) *HTTPClient {
-	c.Client.cjar = jar
-	c.Client.client.Jar = c.Client.cjar
+// Removes cookie by name, also the one inherited from the client
+func (c *Cookie) Del(name string) *HTTPClient {
+	c.Client.request.Cookie = slices.DeleteFunc(slices.Clone(c.Client.request.Cookie), func(cookie *http.Cookie) bool {
+		return cookie.Name == name
+	})
 	return c.Client
 }
 
-// Gets cookies as *http.Cookie
-func (c *Cookie) Get() []*http.Cookie {
-	return c.Client.response().Cookies()
+// Sets cookie jar of the underlying client, this affects all its requests
+func (c *Cookie) SetCookieJar(jar *cookiejar.Jar) *HTTPClient {
+	c.Client.client.SetCookieJar(jar)
+	return c.Client
 }
 
 // Sets path for save
@@ -71,33 +83,67 @@ func (c *Cookie) Path(path string) *Cookie {
 	return c
 }
 
-// Save cookies to file for next requests
-// by default saves to the cookies/domainname directory
-func (c *Cookie) Save() *Cookie {
-	cookies := c.Get()
-	data := SerializableCookies{URL: c.Client.request.url, Cookies: cookies}
-
-	cookieBytes, err := json.Marshal(data)
-	if err != nil {
-		return c
-	}
-	cookieReader := io.NopCloser(bytes.NewReader(cookieBytes))
-	toFilePath := c.loadPath()
-	saveToFile(toFilePath, cookieReader)
-	return c
-}
-
 func (c *Cookie) Load() *Cookie {
 	fromFilePath := c.loadPath()
 	file, err := os.ReadFile(fromFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c
+	}
 	if err != nil {
+		c.client().addError(PhaseBuild, err)
 		return c
 	}
 
 	var data SerializableCookies
 	if err := json.Unmarshal(file, &data); err != nil {
+		c.client().addError(PhaseBuild, err)
 		return c
 	}
 	c.SetCookies(data.Cookies)
 	return c
 }
+
+// Init response cookies
+func (r *Response) Cookie() *ResponseCookie {
+	return &ResponseCookie{Response: r}
+}
+
+func (c *ResponseCookie) loadPath() string {
+	if c.savePath != "" {
+		return c.savePath
+	}
+	return cookiesFilePath(c.Response.GetCurrentUrl().Host)
+}
+
+// Gets cookies as *http.Cookie
+func (c *ResponseCookie) Get() []*http.Cookie {
+	if c.Response.raw == nil {
+		return nil
+	}
+	return c.Response.raw.Cookies()
+}
+
+// Sets path for save
+func (c *ResponseCookie) Path(path string) *ResponseCookie {
+	c.savePath = path
+	return c
+}
+
+// Save cookies to file for next requests
+// by default saves to the cookies/domainname directory
+func (c *ResponseCookie) Save() *ResponseCookie {
+	cookies := c.Get()
+	data := SerializableCookies{URL: c.Response.GetCurrentUrl().String(), Cookies: cookies}
+
+	cookieBytes, err := json.Marshal(data)
+	if err != nil {
+		c.Response.addError(PhaseSave, err)
+		return c
+	}
+	cookieReader := io.NopCloser(bytes.NewReader(cookieBytes))
+	toFilePath := c.loadPath()
+	if err := saveToFile(toFilePath, cookieReader); err != nil {
+		c.Response.addError(PhaseSave, err)
+	}
+	return c
+}
@@ -0,0 +1,82 @@
+package grequest
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// Sets default header of new requests, a request overrides it with Header().Set
+// or removes it with Header().Del
+func (c *Client) SetHeader(key, value string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header.Set(key, value)
+	return c
+}
+
+// Deletes default header of new requests
+func (c *Client) DelHeader(key string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header.Del(key)
+	return c
+}
+
+// Sets default cookies sent with new requests in addition to the cookie jar,
+// a request removes one with Cookie().Del
+func (c *Client) SetCookies(cookies []*http.Cookie) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cookies = slices.Clone(cookies)
+	return c
+}
+
+// Sets default basic auth of new requests, a request removes it with Auth().Remove
+func (c *Client) SetBasicAuth(user, pass string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header.Del(authorization)
+	c.basic = &BasicAuth{User: user, Pass: pass}
+	return c
+}
+
+// Sets default custom auth token of new requests
+func (c *Client) SetToken(token string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.basic = nil
+	c.header.Set(authorization, token)
+	return c
+}
+
+// Sets default bearer token of new requests
+func (c *Client) SetBearer(token string) *Client {
+	return c.SetToken("Bearer " + token)
+}
+
+// Sets default query parameter of new requests, a parameter already in the
+// url of a request is kept and Query().Set or Query().Del override it
+func (c *Client) SetQueryParam(key, value string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.query.Set(key, value)
+	return c
+}
+
+// Deletes default query parameter of new requests
+func (c *Client) DelQueryParam(key string) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.query.Del(key)
+	return c
+}
+
+// Gets query changes which add the default query params missing in the url
+func (c *Client) defaultQuery() []queryOp {
+	var ops []queryOp
+	for _, key := range slices.Sorted(maps.Keys(c.query)) {
+		ops = append(ops, queryOp{key: key, values: slices.Clone(c.query[key]), missing: true})
+	}
+	return ops
+}
@@ -0,0 +1,266 @@
+package grequest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Download saves a response body to a file. The body is written to a .part
+// file next to the target which is renamed once the transfer is complete,
+// an interrupted transfer is resumed from the .part file with a Range request.
+type Download struct {
+	resume   bool
+	segments int
+	digests  []Digest
+	Client   *HTTPClient
+}
+
+// downloadState keeps the validator of the response a .part file came from
+type downloadState struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+}
+
+const (
+	partSuffix      = ".part"
+	partStateSuffix = ".part.json"
+	rangeField      = "Range"
+	ifRangeField    = "If-Range"
+	contentRange    = "Content-Range"
+	acceptRanges    = "Accept-Ranges"
+	// Smallest part of a file worth its own connection
+	minSegmentSize = 1 << 20
+)
+
+// Init download of the response body
+func (c *HTTPClient) Download() *Download {
+	return &Download{resume: true, Client: c}
+}
+
+// Gets http client object
+func (d *Download) client() *HTTPClient {
+	return d.Client
+}
+
+// Sets whether an existing .part file is resumed, enabled by default
+func (d *Download) Resume(resume bool) *Download {
+	d.resume = resume
+	return d
+}
+
+// Sets count of byte ranges fetched in parallel, 1 = single stream
+// Falls back to a single stream when the server does not support ranges
+func (d *Download) Segments(segments int) *Download {
+	d.segments = segments
+	return d
+}
+
+// Downloads response body to the file, the timeout of the request bounds
+// getting the response headers and not the transfer of the body
+func (d *Download) ToFile(fileName string) *Response {
+	return d.toFile(nil, fileName)
+}
+
+// Downloads response body to the file with context, the context bounds the whole transfer
+func (d *Download) ToFileWithContext(ctx context.Context, fileName string) *Response {
+	return d.toFile(ctx, fileName)
+}
+
+func (d *Download) toFile(ctx context.Context, fileName string) *Response {
+	partName := fileName + partSuffix
+	var offset int64
+	requestURL := d.client().GetCurrentUrl().String()
+	state := readDownloadState(fileName)
+	// A .part file of another url is never resumed, even with a matching validator
+	if state != nil && state.URL != requestURL {
+		state = nil
+	}
+	if d.resume && state != nil && state.validator() != "" {
+		if info, err := os.Stat(partName); err == nil {
+			offset = info.Size()
+		}
+	}
+	if offset == 0 && d.segments > 1 {
+		if res := d.toFileSegmented(ctx, fileName); res != nil {
+			return res
+		}
+	}
+
+	req := d.client().clone().Stream()
+	if offset > 0 {
+		req.request.header.Set(rangeField, fmt.Sprintf("bytes=%d-", offset))
+		req.request.header.Set(ifRangeField, state.validator())
+	}
+	start := time.Now()
+	res := req.doContext(ctx)
+	defer res.Close()
+	// Elapsed time of a download includes the transfer of the body
+	defer func() {
+		res.elapsed = time.Since(start)
+	}()
+	if res.raw == nil {
+		return res
+	}
+
+	switch res.raw.StatusCode {
+	case http.StatusOK:
+		offset = 0
+		state = &downloadState{
+			URL:          requestURL,
+			ETag:         res.raw.Header.Get(etagField),
+			LastModified: res.raw.Header.Get(lastModifiedField),
+		}
+		if err := writeDownloadState(fileName, state); err != nil {
+			res.addError(PhaseSave, err)
+			return res
+		}
+	case http.StatusPartialContent:
+		start, _, ok := parseContentRange(res.raw.Header.Get(contentRange))
+		if !ok || start != offset {
+			res.addError(PhaseStatus, fmt.Errorf("%w: unexpected Content-Range %q", ErrBadStatus, res.raw.Header.Get(contentRange)))
+			return res
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		if _, total, ok := parseContentRange(res.raw.Header.Get(contentRange)); ok && offset > 0 && total == offset {
+			return d.finish(res, fileName, nil)
+		}
+		if offset > 0 {
+			// The .part file does not match the resource anymore, start over
+			removeDownloadState(fileName)
+			restart := *d
+			restart.resume = false
+			return restart.toFile(ctx, fileName)
+		}
+		fallthrough
+	default:
+		res.addError(PhaseStatus, fmt.Errorf("%w: %s", ErrBadStatus, res.raw.Status))
+		return res
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0777); err != nil {
+		res.addError(PhaseSave, err)
+		return res
+	}
+	file, err := os.OpenFile(partName, flags, 0666)
+	if err != nil {
+		res.addError(PhaseSave, err)
+		return res
+	}
+	_, err = io.Copy(file, res.Body().get())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		res.addError(copyPhase(err), err)
+		return res
+	}
+	return d.finish(res, fileName, responseDigests(res.raw))
+}
+
+// Verifies the complete .part file and moves it to its final name,
+// a file which does not match the digests is deleted
+func (d *Download) finish(res *Response, fileName string, announced []Digest) *Response {
+	if err := verifyFile(fileName+partSuffix, d.digests, announced); err != nil {
+		os.Remove(fileName + partSuffix)
+		removeDownloadState(fileName)
+		res.addError(PhaseVerify, err)
+		return res
+	}
+	if err := os.Rename(fileName+partSuffix, fileName); err != nil {
+		res.addError(PhaseSave, err)
+		return res
+	}
+	removeDownloadState(fileName)
+	return res
+}
+
+// Gets phase of an error returned by io.Copy from the network to a file
+func copyPhase(err error) Phase {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return PhaseSave
+	}
+	return PhaseRead
+}
+
+// Gets value for If-Range, weak etags can not be used for ranges
+func (s *downloadState) validator() string {
+	if s.ETag != "" && !strings.HasPrefix(s.ETag, "W/") {
+		return s.ETag
+	}
+	return s.LastModified
+}
+
+func readDownloadState(fileName string) *downloadState {
+	data, err := os.ReadFile(fileName + partStateSuffix)
+	if err != nil {
+		return nil
+	}
+	var state downloadState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil
+	}
+	return &state
+}
+
+func writeDownloadState(fileName string, state *downloadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fileName), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName+partStateSuffix, data, 0666)
+}
+
+func removeDownloadState(fileName string) {
+	os.Remove(fileName + partStateSuffix)
+}
+
+// Parses Content-Range like "bytes 100-199/200" or "bytes */200",
+// returns first byte position and complete length, -1 when unknown
+func parseContentRange(value string) (start int64, total int64, ok bool) {
+	unit, spec, found := strings.Cut(strings.TrimSpace(value), " ")
+	if !found || unit != "bytes" {
+		return 0, 0, false
+	}
+	rangeSpec, totalSpec, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	total = -1
+	if totalSpec != "*" {
+		var err error
+		if total, err = strconv.ParseInt(totalSpec, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	if rangeSpec == "*" {
+		return -1, total, true
+	}
+	first, _, found := strings.Cut(rangeSpec, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, total, true
+}
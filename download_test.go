@@ -0,0 +1,156 @@
+package grequest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value string
+		start int64
+		total int64
+		ok    bool
+	}{
+		{"bytes 100-199/200", 100, 200, true},
+		{"bytes 0-99/*", 0, -1, true},
+		{"bytes */200", -1, 200, true},
+		{" bytes 5-9/10 ", 5, 10, true},
+		{"items 0-9/10", 0, 0, false},
+		{"bytes 0-9", 0, 0, false},
+		{"bytes x-9/10", 0, 0, false},
+		{"bytes 0-9/x", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, total, ok := parseContentRange(tt.value)
+		if start != tt.start || total != tt.total || ok != tt.ok {
+			t.Errorf("parseContentRange(%q) = %d, %d, %v, want %d, %d, %v", tt.value, start, total, ok, tt.start, tt.total, tt.ok)
+		}
+	}
+}
+
+// Writes a .part file with its state as an interrupted download leaves it
+func writePart(t *testing.T, fileName string, part []byte, state *downloadState) {
+	t.Helper()
+	if err := os.WriteFile(fileName+partSuffix, part, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeDownloadState(fileName, state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkFile(t *testing.T, fileName string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("file has %d bytes, want the %d bytes of the content", len(got), len(want))
+	}
+	if _, err := os.Stat(fileName + partStateSuffix); !os.IsNotExist(err) {
+		t.Fatal("download state is left behind")
+	}
+}
+
+func TestDownloadResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var ranges atomic.Int32
+	srv := rangeServer(content, &ranges)
+	defer srv.Close()
+	// The cache must not answer the range request with the stored full response
+	client := NewClient().SetCache(NewMemoryCache(10))
+	if err := client.Get(srv.URL).Do().Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "file.bin")
+	writePart(t, fileName, content[:4000], &downloadState{URL: srv.URL, ETag: `"v1"`})
+	if err := client.Get(srv.URL).Download().ToFile(fileName).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if ranges.Load() != 1 {
+		t.Fatalf("range requests = %d, want 1", ranges.Load())
+	}
+	checkFile(t, fileName, content)
+}
+
+func TestDownloadIgnoresPartOfOtherURL(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var ranges atomic.Int32
+	srv := rangeServer(content, &ranges)
+	defer srv.Close()
+
+	fileName := filepath.Join(t.TempDir(), "file.bin")
+	writePart(t, fileName, bytes.Repeat([]byte("x"), 4000), &downloadState{URL: srv.URL + "/other", ETag: `"v1"`})
+	if err := NewClient().Get(srv.URL).Download().ToFile(fileName).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if ranges.Load() != 0 {
+		t.Fatalf("range requests = %d, want 0", ranges.Load())
+	}
+	checkFile(t, fileName, content)
+}
+
+func TestDownloadRestartKeepsResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var ranges atomic.Int32
+	srv := rangeServer(content, &ranges)
+	defer srv.Close()
+
+	// A .part file longer than the resource is answered with 416 and started over
+	fileName := filepath.Join(t.TempDir(), "file.bin")
+	writePart(t, fileName, bytes.Repeat([]byte("x"), len(content)+100), &downloadState{URL: srv.URL, ETag: `"v1"`})
+	download := NewClient().Get(srv.URL).Download()
+	if err := download.ToFile(fileName).Err(); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, fileName, content)
+	if !download.resume {
+		t.Fatal("restart disabled resuming of the download")
+	}
+}
+
+// slowWriter sends headers at once and sleeps before every write of the body
+type slowWriter struct {
+	http.ResponseWriter
+	delay time.Duration
+}
+
+func (w slowWriter) WriteHeader(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w slowWriter) Write(p []byte) (int, error) {
+	time.Sleep(w.delay)
+	return w.ResponseWriter.Write(p)
+}
+
+func TestDownloadTimeoutBoundsHeadersOnly(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), 2*minSegmentSize)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(slowWriter{w, 10 * time.Millisecond}, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer srv.Close()
+	timeout := 100 * time.Millisecond
+	for _, segments := range []int{1, 2} {
+		fileName := filepath.Join(t.TempDir(), "file.bin")
+		res := NewClient().Get(srv.URL).SetTimeout(timeout).Download().Segments(segments).ToFile(fileName)
+		if err := res.Err(); err != nil {
+			t.Fatalf("segments %d: %v", segments, err)
+		}
+		checkFile(t, fileName, content)
+		if res.Elapsed() <= timeout {
+			t.Errorf("segments %d: elapsed %s does not include the transfer", segments, res.Elapsed())
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package grequest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// Phase tells at which step of a call an error happened
+type Phase string
+
+const (
+	PhaseBuild    Phase = "build"
+	PhaseDial     Phase = "dial"
+	PhaseTLS      Phase = "tls"
+	PhaseStatus   Phase = "status"
+	PhaseRedirect Phase = "redirect"
+	PhaseRead     Phase = "read"
+	PhaseDecode   Phase = "decode"
+	PhaseVerify   Phase = "verify"
+	PhaseSave     Phase = "save"
+	PhaseCircuit  Phase = "circuit"
+)
+
+var (
+	ErrInvalidHost             = errors.New("Invalid Host Request")
+	ErrInvalidURL              = errors.New("Invalid URL")
+	ErrInvalidRedirectLocation = errors.New("Invalid Redirect Location")
+	ErrTooManyRedirection      = errors.New("Too many Redirect")
+	ErrTooManyRetry            = errors.New("Too many Retry")
+	ErrBodyNotRewindable       = errors.New("Request body can not be sent again")
+	ErrBadStatus               = errors.New("Bad Status")
+	ErrChecksumMismatch        = errors.New("Checksum mismatch")
+	ErrUnsupportedValue        = errors.New("Unsupported value for form encoding")
+	ErrMissingPathParam        = errors.New("Missing path parameter")
+	ErrNoResponse              = errors.New("No response")
+	ErrCircuitOpen             = errors.New("Circuit open")
+)
+
+// RequestError describes a failure of a request together with the step it
+// happened at. Use errors.Is and errors.As to inspect the wrapped cause.
+type RequestError struct {
+	Phase   Phase
+	Method  string
+	URL     string
+	Attempt int
+	Err     error
+}
+
+func (e *RequestError) Error() string {
+	msg := "grequest: " + string(e.Phase)
+	if e.Method != "" || e.URL != "" {
+		msg = fmt.Sprintf("%s %s %s", msg, e.Method, e.URL)
+	}
+	if e.Attempt > 1 {
+		msg = fmt.Sprintf("%s (attempt %d)", msg, e.Attempt)
+	}
+	return fmt.Sprintf("%s: %v", msg, e.Err)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// Gets phase of an error returned while sending a request
+func errorPhase(err error) Phase {
+	var recordErr tls.RecordHeaderError
+	var alertErr tls.AlertError
+	var verifyErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return PhaseCircuit
+	case errors.Is(err, ErrBodyNotRewindable):
+		return PhaseBuild
+	case errors.As(err, &pathErr):
+		// A file of the request body could not be read while sending
+		return PhaseBuild
+	case errors.Is(err, ErrTooManyRetry):
+		return PhaseStatus
+	case errors.Is(err, ErrTooManyRedirection), errors.Is(err, ErrInvalidRedirectLocation):
+		return PhaseRedirect
+	case errors.As(err, &recordErr),
+		errors.As(err, &alertErr),
+		errors.As(err, &verifyErr),
+		errors.As(err, &authorityErr),
+		errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr):
+		return PhaseTLS
+	}
+	return PhaseDial
+}
@@ -1,70 +1,202 @@
 package grequest
 
 import (
+	"fmt"
 	"io"
+	"mime"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 )
 
+// Collision tells what SaveFile does when the file already exists
+type Collision int
+
+const (
+	// Replace the existing file
+	CollisionOverwrite Collision = iota
+	// Save as name-1.ext, name-2.ext and so on
+	CollisionSuffix
+	// Keep the existing file and do not save
+	CollisionSkip
+)
+
+const (
+	contentDisposition = "Content-Disposition"
+	defaultFileName    = "index"
+	maxFileNameLength  = 255
+)
+
+// Extensions by media type, mime.ExtensionsByType is asked for anything else
+var extensionsByMediaType = map[string]string{
+	"image/jpeg":                    ".jpg",
+	"image/png":                     ".png",
+	"image/gif":                     ".gif",
+	"image/bmp":                     ".bmp",
+	"image/webp":                    ".webp",
+	"image/svg+xml":                 ".svg",
+	"image/tiff":                    ".tiff",
+	"image/avif":                    ".avif",
+	"image/heic":                    ".heic",
+	"image/x-icon":                  ".ico",
+	"image/vnd.microsoft.icon":      ".ico",
+	"application/pdf":               ".pdf",
+	"application/msword":            ".doc",
+	"application/rtf":               ".rtf",
+	"application/epub+zip":          ".epub",
+	"application/vnd.ms-excel":      ".xls",
+	"application/vnd.ms-powerpoint": ".ppt",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/vnd.oasis.opendocument.text":                                   ".odt",
+	"application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+	"application/vnd.oasis.opendocument.presentation":                           ".odp",
+	"application/zip":                         ".zip",
+	"application/gzip":                        ".gz",
+	"application/x-gzip":                      ".gz",
+	"application/x-tar":                       ".tar",
+	"application/x-bzip2":                     ".bz2",
+	"application/x-xz":                        ".xz",
+	"application/zstd":                        ".zst",
+	"application/x-7z-compressed":             ".7z",
+	"application/x-rar-compressed":            ".rar",
+	"application/vnd.rar":                     ".rar",
+	"application/java-archive":                ".jar",
+	"application/vnd.android.package-archive": ".apk",
+	"application/vnd.debian.binary-package":   ".deb",
+	"application/x-debian-package":            ".deb",
+	"application/x-rpm":                       ".rpm",
+	"application/x-iso9660-image":             ".iso",
+	"application/x-msdownload":                ".exe",
+	"application/octet-stream":                ".bin",
+	"application/wasm":                        ".wasm",
+	"application/json":                        ".json",
+	"application/ld+json":                     ".jsonld",
+	"application/x-ndjson":                    ".ndjson",
+	"application/xml":                         ".xml",
+	"application/xhtml+xml":                   ".xhtml",
+	"application/atom+xml":                    ".atom",
+	"application/rss+xml":                     ".rss",
+	"application/yaml":                        ".yaml",
+	"application/toml":                        ".toml",
+	"application/sql":                         ".sql",
+	"application/javascript":                  ".js",
+	"application/x-sh":                        ".sh",
+	"text/html":                               ".html",
+	"text/plain":                              ".txt",
+	"text/css":                                ".css",
+	"text/csv":                                ".csv",
+	"text/javascript":                         ".js",
+	"text/markdown":                           ".md",
+	"text/xml":                                ".xml",
+	"text/yaml":                               ".yaml",
+	"text/calendar":                           ".ics",
+	"font/ttf":                                ".ttf",
+	"font/otf":                                ".otf",
+	"font/woff":                               ".woff",
+	"font/woff2":                              ".woff2",
+	"audio/mpeg":                              ".mp3",
+	"audio/wav":                               ".wav",
+	"audio/x-wav":                             ".wav",
+	"audio/ogg":                               ".ogg",
+	"audio/opus":                              ".opus",
+	"audio/aac":                               ".aac",
+	"audio/flac":                              ".flac",
+	"audio/mp4":                               ".m4a",
+	"audio/webm":                              ".weba",
+	"video/mp4":                               ".mp4",
+	"video/webm":                              ".webm",
+	"video/ogg":                               ".ogv",
+	"video/x-msvideo":                         ".avi",
+	"video/mpeg":                              ".mpeg",
+	"video/quicktime":                         ".mov",
+	"video/x-matroska":                        ".mkv",
+	"video/3gpp":                              ".3gp",
+	"video/x-flv":                             ".flv",
+}
+
+// Gets file extension by content type, parameters like charset are ignored
 func getFileExtensionByContentType(contentType string) string {
-	switch contentType {
-	case "image/jpeg":
-		return ".jpg"
-	case "image/png":
-		return ".png"
-	case "image/gif":
-		return ".gif"
-	case "image/bmp":
-		return ".bmp"
-	case "image/webp":
-		return ".webp"
-	case "application/pdf":
-		return ".pdf"
-	case "application/msword":
-		return ".doc"
-	case "application/vnd.openxmlformats-officedocument.wordprocessingml.document":
-		return ".docx"
-	case "application/vnd.ms-excel":
-		return ".xls"
-	case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
-		return ".xlsx"
-	case "application/vnd.ms-powerpoint":
-		return ".ppt"
-	case "application/vnd.openxmlformats-officedocument.presentationml.presentation":
-		return ".pptx"
-	case "application/zip":
-		return ".zip"
-	case "application/x-rar-compressed":
-		return ".rar"
-	case "application/json":
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(contentType, ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	}
+	if mediaType == "" {
+		return ""
+	}
+	if extension, ok := extensionsByMediaType[mediaType]; ok {
+		return extension
+	}
+	if extensions, err := mime.ExtensionsByType(mediaType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
 		return ".json"
-	case "application/xml":
+	case strings.HasSuffix(mediaType, "+xml"):
 		return ".xml"
-	case "text/html":
-		return ".html"
-	case "text/plain":
-		return ".txt"
-	case "text/css":
-		return ".css"
-	case "text/javascript":
-		return ".js"
-	case "audio/mpeg":
-		return ".mp3"
-	case "audio/wav":
-		return ".wav"
-	case "audio/ogg":
-		return ".ogg"
-	case "video/mp4":
-		return ".mp4"
-	case "video/webm":
-		return ".webm"
-	case "video/x-msvideo":
-		return ".avi"
-	case "video/mpeg":
-		return ".mpeg"
-	default:
+	case strings.HasSuffix(mediaType, "+zip"):
+		return ".zip"
+	}
+	return ""
+}
+
+// Gets file name from Content-Disposition, filename* included
+func getFileNameByContentDisposition(value string) string {
+	if value == "" {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(value)
+	if err != nil {
 		return ""
 	}
+	return params["filename"]
+}
+
+// Makes a name safe to be used as a single file in a directory
+func sanitizeFileName(name string) string {
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if len(name) > maxFileNameLength {
+		extension := filepath.Ext(name)
+		if len(extension) > maxFileNameLength/8 {
+			extension = ""
+		}
+		base := strings.ToValidUTF8(name[:maxFileNameLength-len(extension)], "")
+		name = base + extension
+	}
+	return name
+}
+
+// Gets the file name to use according to the collision mode,
+// false when the file exists and must be skipped
+func resolveCollision(fileName string, collision Collision) (string, bool) {
+	if _, err := os.Stat(fileName); err != nil {
+		return fileName, true
+	}
+	switch collision {
+	case CollisionSkip:
+		return fileName, false
+	case CollisionSuffix:
+		extension := filepath.Ext(fileName)
+		base := strings.TrimSuffix(fileName, extension)
+		for i := 1; ; i++ {
+			candidate := fmt.Sprintf("%s-%d%s", base, i, extension)
+			if _, err := os.Stat(candidate); err != nil {
+				return candidate, true
+			}
+		}
+	}
+	return fileName, true
 }
 
 func readFileByPath(path string) (*os.File, error) {
@@ -80,17 +212,29 @@ func getFileNameByPath(path string) string {
 	return fileName
 }
 
-func saveToFile(fileName string, src io.Reader) error {
-	_ = os.MkdirAll(filepath.Dir(fileName), 0777)
-	out, err := os.Create(fileName)
+// Writes src to a temporary file which replaces fileName once it is complete
+// and matches every set of digests
+func saveToFile(fileName string, src io.Reader, digestSets ...[]Digest) error {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0777); err != nil {
+		return err
+	}
+	partName := fileName + partSuffix
+	out, err := os.Create(partName)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
 
-	_, err = io.Copy(out, src)
+	hashes := newDigestWriter(slices.Concat(digestSets...))
+	_, err = io.Copy(out, io.TeeReader(src, hashes))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = hashes.verify(digestSets...)
+	}
 	if err != nil {
+		os.Remove(partName)
 		return err
 	}
-	return nil
+	return os.Rename(partName, fileName)
 }
@@ -0,0 +1,120 @@
+package grequest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetFileNameByContentDisposition(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`attachment; filename="report.pdf"`, "report.pdf"},
+		{`attachment; filename=plain.txt`, "plain.txt"},
+		{`attachment; filename*=UTF-8''na%C3%AFve%20file.txt`, "naïve file.txt"},
+		{`inline`, ""},
+		{`attachment; filename="unterminated`, ""},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		if got := getFileNameByContentDisposition(tt.value); got != tt.want {
+			t.Errorf("getFileNameByContentDisposition(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`..\..\boot.ini`, "boot.ini"},
+		{"a<b>:c|d?e*.txt", "a_b__c_d_e_.txt"},
+		{"tab\there", "tab_here"},
+		{" .hidden. ", "hidden"},
+		{"..", ""},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFileName(tt.name); got != tt.want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	long := sanitizeFileName(strings.Repeat("a", 300) + ".txt")
+	if len(long) != maxFileNameLength || !strings.HasSuffix(long, ".txt") {
+		t.Errorf("long name has %d bytes and ends with %q", len(long), long[len(long)-4:])
+	}
+}
+
+func TestGetFileExtensionByContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"image/jpeg", ".jpg"},
+		{"application/pdf; charset=binary", ".pdf"},
+		{"APPLICATION/PDF", ".pdf"},
+		{"application/vnd.api+json", ".json"},
+		{"application/vnd.example+xml", ".xml"},
+		{"application/x-unknown-type", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := getFileExtensionByContentType(tt.contentType); got != tt.want {
+			t.Errorf("getFileExtensionByContentType(%q) = %q, want %q", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestResolveCollision(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "file.txt")
+	if got, ok := resolveCollision(fileName, CollisionSkip); got != fileName || !ok {
+		t.Fatalf("missing file = %q, %v", got, ok)
+	}
+	for _, name := range []string{"file.txt", "file-1.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, ok := resolveCollision(fileName, CollisionOverwrite); got != fileName || !ok {
+		t.Errorf("overwrite = %q, %v", got, ok)
+	}
+	if _, ok := resolveCollision(fileName, CollisionSkip); ok {
+		t.Error("skip saved over the existing file")
+	}
+	if got, ok := resolveCollision(fileName, CollisionSuffix); got != filepath.Join(dir, "file-2.txt") || !ok {
+		t.Errorf("suffix = %q, %v", got, ok)
+	}
+}
+
+func TestResponseFileName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download":
+			w.Header().Set(contentDisposition, `attachment; filename="../report.pdf"`)
+		case "/photo":
+			w.Header().Set(contentType, "image/png")
+		}
+	}))
+	defer srv.Close()
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/download", "report.pdf"},
+		{"/photo", "photo.png"},
+		{"/", "index"},
+	}
+	for _, tt := range tests {
+		if got := NewClient().Get(srv.URL + tt.path).Do().Body().FileName(); got != tt.want {
+			t.Errorf("FileName of %s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
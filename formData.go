@@ -1,27 +1,51 @@
 package grequest
 
 import (
-	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"maps"
+	"mime"
 	"mime/multipart"
+	"net/textproto"
 	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 )
 
 type FormData struct {
-	formDataContentType string
-	urlValues           url.Values
-	isMultipartForm     bool
-	body                *bytes.Buffer
-	writer              *multipart.Writer
-	Client              *HTTPClient
+	encoder         valuesEncoder
+	urlValues       url.Values
+	isMultipartForm bool
+	boundary        string
+	parts           []*formPart
+	Client          *HTTPClient
 }
 
+// formPart is a part of a multipart body, its content is read when the request is sent
+type formPart struct {
+	header textproto.MIMEHeader
+	body   *requestBody
+}
+
+// byteCounter is a writer which only counts bytes
+type byteCounter int64
+
+const (
+	octetStream     = "application/octet-stream"
+	applicationJson = "application/json"
+)
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
 // Init status
 func (c *HTTPClient) FormData() *FormData {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
+	boundary := multipart.NewWriter(io.Discard).Boundary()
 	urlValues := url.Values{}
-	return &FormData{Client: c, writer: writer, body: body, urlValues: urlValues}
+	return &FormData{Client: c, boundary: boundary, urlValues: urlValues}
 }
 
 // Gets http client object
@@ -50,10 +74,16 @@ func (c *FormData) SetFieldEncode(key, value string) *FormData {
 
 // Sets form data field
 func (c *FormData) setFieldMultipart(fieldname, value string) *FormData {
-	_ = c.writer.WriteField(fieldname, value)
+	c.parts = append(c.parts, newFieldPart(fieldname, value))
 	return c
 }
 
+func newFieldPart(fieldname, value string) *formPart {
+	header := make(textproto.MIMEHeader)
+	header.Set(contentDisposition, `form-data; name="`+quoteEscaper.Replace(fieldname)+`"`)
+	return &formPart{header: header, body: newBytesBody([]byte(value))}
+}
+
 // Sets form data fields
 func (c *FormData) SetFields(fields *map[string]string) *HTTPClient {
 	for k, val := range *fields {
@@ -66,44 +96,205 @@ func (c *FormData) SetFields(fields *map[string]string) *HTTPClient {
 	return c.Push()
 }
 
-// Attach file to request
+// Sets form data fields from url.Values, a map or a struct with form tags,
+// see EncodeValues
+func (c *FormData) AddValues(data interface{}) *FormData {
+	values, err := c.encoder.encode(data)
+	if err != nil {
+		c.client().addError(PhaseBuild, err)
+		return c
+	}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		for _, value := range values[key] {
+			c.AddField(key, value)
+		}
+	}
+	return c
+}
+
+// Sets how keys of nested structs and maps are joined, brackets by default
+func (c *FormData) SetNestedStyle(style NestedStyle) *FormData {
+	c.encoder.nested = style
+	return c
+}
+
+// Attach file to request, the file is read when the request is sent
+// Content type of the part is guessed from the file extension
 func (c *FormData) AddFile(key, path string) *FormData {
 	c.WithMultipart()
-	file, err := readFileByPath(path)
-	defer file.Close()
+	info, err := os.Stat(path)
 	if err != nil {
+		c.client().addError(PhaseBuild, err)
 		return c
 	}
-	fileName := getFileNameByPath(path)
-	part, err := c.writer.CreateFormFile(key, fileName)
+	body := &requestBody{
+		open: func() (io.ReadCloser, error) {
+			return readFileByPath(path)
+		},
+		length:     info.Size(),
+		rewindable: true,
+	}
+	mediaType := mime.TypeByExtension(filepath.Ext(path))
+	if mediaType == "" {
+		mediaType = octetStream
+	}
+	c.addFilePart(key, getFileNameByPath(path), mediaType, body)
+	return c
+}
+
+// Attach several files under the same field name
+func (c *FormData) AddFiles(key string, paths ...string) *FormData {
+	for _, path := range paths {
+		c.AddFile(key, path)
+	}
+	return c
+}
+
+// Attach file read from the reader, seekable readers can be sent many times
+// Empty mediaType means application/octet-stream
+func (c *FormData) AddFileReader(key, fileName, mediaType string, file io.Reader) *FormData {
+	c.WithMultipart()
+	body, err := newReaderBody(file)
 	if err != nil {
+		c.client().addError(PhaseBuild, err)
 		return c
 	}
-	_, err = io.Copy(part, file)
+	if mediaType == "" {
+		mediaType = octetStream
+	}
+	c.addFilePart(key, fileName, mediaType, body)
+	return c
+}
+
+// Attach json encoded data as a part with application/json content type
+func (c *FormData) AddJson(key string, data interface{}) *FormData {
+	c.WithMultipart()
+	jsonData, err := json.Marshal(data)
 	if err != nil {
+		c.client().addError(PhaseBuild, err)
 		return c
 	}
+	part := newFieldPart(key, string(jsonData))
+	part.header.Set(contentType, applicationJson)
+	c.parts = append(c.parts, part)
+	return c
+}
 
-	c.formDataContentType = c.writer.FormDataContentType()
+// Sets header of the last added part, Content-Disposition can not be changed
+func (c *FormData) SetPartHeader(key, value string) *FormData {
+	if len(c.parts) == 0 {
+		c.client().addError(PhaseBuild, errors.New("form data has no part to set a header on"))
+		return c
+	}
+	key = textproto.CanonicalMIMEHeaderKey(key)
+	if key == contentDisposition {
+		c.client().addError(PhaseBuild, fmt.Errorf("%s of a part is set from its name", contentDisposition))
+		return c
+	}
+	c.parts[len(c.parts)-1].header.Set(key, value)
 	return c
 }
 
+func (c *FormData) addFilePart(key, fileName, mediaType string, body *requestBody) {
+	header := make(textproto.MIMEHeader)
+	header.Set(contentDisposition, `form-data; name="`+quoteEscaper.Replace(key)+
+		`"; filename="`+quoteEscaper.Replace(fileName)+`"`)
+	header.Set(contentType, mediaType)
+	c.parts = append(c.parts, &formPart{header: header, body: body})
+}
+
 // Push form fields
 func (c *FormData) Push() *HTTPClient {
-	c.writer.Close()
 	if !c.isMultipartForm {
 		c.client().ContentType().SetFormUrlencoded()
 		c.client().Body().SetString(c.urlValues.Encode())
+		return c.client()
 	}
-	if c.isMultipartForm {
-		if c.formDataContentType != "" {
-			c.client().ContentType().Set(c.formDataContentType)
+	// Fields added before the form became multipart go first
+	var parts []*formPart
+	for _, key := range slices.Sorted(maps.Keys(c.urlValues)) {
+		for _, value := range c.urlValues[key] {
+			parts = append(parts, newFieldPart(key, value))
 		}
-		if c.formDataContentType == "" {
-			c.client().ContentType().SetMultipartFormData()
-		}
-		c.client().Body().Set(c.body)
 	}
+	parts = append(parts, c.parts...)
 
+	c.client().ContentType().Set(mime.FormatMediaType(multipartFormData, map[string]string{"boundary": c.boundary}))
+	c.client().request.body = newMultipartBody(c.boundary, parts)
 	return c.client()
 }
+
+// Gets body which writes the parts through a pipe while the request is sent,
+// its length is known when the length of every part is known
+func newMultipartBody(boundary string, parts []*formPart) *requestBody {
+	rewindable := true
+	for _, part := range parts {
+		rewindable = rewindable && part.body.rewindable
+	}
+	return &requestBody{
+		open: func() (io.ReadCloser, error) {
+			pr, pw := io.Pipe()
+			go func() {
+				pw.CloseWithError(writeMultipart(pw, boundary, parts))
+			}()
+			return pr, nil
+		},
+		length:     multipartLength(boundary, parts),
+		rewindable: rewindable,
+	}
+}
+
+func writeMultipart(w io.Writer, boundary string, parts []*formPart) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		dst, err := writer.CreatePart(part.header)
+		if err != nil {
+			return err
+		}
+		src, err := part.body.open()
+		if err != nil {
+			return err
+		}
+		if part.body.length >= 0 {
+			// Content-Length is already sent, a file changed since must not break the body
+			_, err = io.CopyN(dst, src, part.body.length)
+		} else {
+			_, err = io.Copy(dst, src)
+		}
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// Gets length of the multipart body, -1 when a part has unknown length
+func multipartLength(boundary string, parts []*formPart) int64 {
+	var counter byteCounter
+	writer := multipart.NewWriter(&counter)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return -1
+	}
+	for _, part := range parts {
+		if part.body.length < 0 {
+			return -1
+		}
+		if _, err := writer.CreatePart(part.header); err != nil {
+			return -1
+		}
+		counter += byteCounter(part.body.length)
+	}
+	if err := writer.Close(); err != nil {
+		return -1
+	}
+	return int64(counter)
+}
+
+func (b *byteCounter) Write(p []byte) (int, error) {
+	*b += byteCounter(len(p))
+	return len(p), nil
+}
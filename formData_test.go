@@ -0,0 +1,154 @@
+package grequest
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// formRequest is what the server read from a multipart request
+type formRequest struct {
+	contentLength int64
+	parts         []formRequestPart
+}
+
+type formRequestPart struct {
+	name     string
+	fileName string
+	header   map[string]string
+	content  string
+}
+
+// Serves 503 for the first failures requests and records every request
+func formServer(t *testing.T, failures int32, requests chan<- formRequest) *httptest.Server {
+	var count atomic.Int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, params, err := mime.ParseMediaType(r.Header.Get(contentType))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		got := formRequest{contentLength: r.ContentLength}
+		reader := multipart.NewReader(r.Body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			content, _ := io.ReadAll(part)
+			got.parts = append(got.parts, formRequestPart{
+				name:     part.FormName(),
+				fileName: part.FileName(),
+				header:   map[string]string{contentType: part.Header.Get(contentType), "X-Part": part.Header.Get("X-Part")},
+				content:  string(content),
+			})
+		}
+		requests <- got
+		if count.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+}
+
+func TestMultipartLength(t *testing.T) {
+	parts := []*formPart{
+		newFieldPart("name", "John"),
+		{header: newFieldPart("file", "").header, body: newBytesBody(bytes.Repeat([]byte("x"), 1000))},
+	}
+	var buf bytes.Buffer
+	if err := writeMultipart(&buf, "boundary", parts); err != nil {
+		t.Fatal(err)
+	}
+	if got := multipartLength("boundary", parts); got != int64(buf.Len()) {
+		t.Fatalf("multipartLength = %d, want %d", got, buf.Len())
+	}
+	parts = append(parts, &formPart{header: newFieldPart("stream", "").header, body: newStreamBody(strings.NewReader("s"))})
+	if got := multipartLength("boundary", parts); got != -1 {
+		t.Fatalf("multipartLength with a stream = %d, want -1", got)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.json")
+	if err := os.WriteFile(path, []byte(`{"n":1}`), 0666); err != nil {
+		t.Fatal(err)
+	}
+	requests := make(chan formRequest, 2)
+	srv := formServer(t, 1, requests)
+	defer srv.Close()
+
+	res := NewClient().Post(srv.URL).
+		RetryIf(http.StatusServiceUnavailable).
+		RetryBackoff(time.Millisecond, time.Millisecond).
+		FormData().
+		AddField("name", "John").
+		AddFile("doc", path).
+		AddFileReader("data", "data.bin", "", strings.NewReader("binary")).
+		SetPartHeader("X-Part", "last").
+		AddJson("meta", map[string]int{"a": 1}).
+		Push().
+		Do()
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	want := []formRequestPart{
+		{name: "name", content: "John"},
+		{name: "doc", fileName: "notes.json", header: map[string]string{contentType: applicationJson}, content: `{"n":1}`},
+		{name: "data", fileName: "data.bin", header: map[string]string{contentType: octetStream, "X-Part": "last"}, content: "binary"},
+		{name: "meta", header: map[string]string{contentType: applicationJson}, content: `{"a":1}`},
+	}
+	// The retry sends the same body again
+	for attempt := range 2 {
+		got := <-requests
+		if got.contentLength <= 0 {
+			t.Fatalf("attempt %d: Content-Length = %d", attempt, got.contentLength)
+		}
+		if len(got.parts) != len(want) {
+			t.Fatalf("attempt %d: %d parts, want %d", attempt, len(got.parts), len(want))
+		}
+		for i, part := range got.parts {
+			w := want[i]
+			if part.name != w.name || part.fileName != w.fileName || part.content != w.content {
+				t.Errorf("attempt %d: part %d = %+v, want %+v", attempt, i, part, w)
+			}
+			for key, value := range w.header {
+				if part.header[key] != value {
+					t.Errorf("attempt %d: part %d %s = %q, want %q", attempt, i, key, part.header[key], value)
+				}
+			}
+		}
+	}
+}
+
+func TestMultipartUnknownLengthIsChunked(t *testing.T) {
+	requests := make(chan formRequest, 1)
+	srv := formServer(t, 0, requests)
+	defer srv.Close()
+
+	res := NewClient().Post(srv.URL).
+		FormData().
+		AddFileReader("data", "data.bin", "", io.MultiReader(strings.NewReader("stream"))).
+		Push().
+		Do()
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	got := <-requests
+	if got.contentLength != -1 || len(got.parts) != 1 || got.parts[0].content != "stream" {
+		t.Fatalf("request = %+v", got)
+	}
+}
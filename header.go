@@ -9,6 +9,10 @@ type Header struct {
 	Client *HTTPClient
 }
 
+type ResponseHeader struct {
+	Response *Response
+}
+
 // Sets the header with io.Reader
 func (c *HTTPClient) Header() *Header {
 	return &Header{Client: c}
@@ -19,37 +23,15 @@ func (c *Header) client() *HTTPClient {
 	return c.Client
 }
 
-// Gets raw http.Header from body
-func (c *Header) get() *http.Header {
-	return &c.client().response().Header
-}
-
-// Gets header and convert in string map
-func (c *Header) GetWithStringMap() map[string]string {
-	var headersMap = make(map[string]string)
-	headers := *c.get()
-	for key, values := range headers {
-		for _, value := range values {
-			headersMap[fmt.Sprintf("%s", key)] = fmt.Sprintf("%s", value)
-		}
-	}
-	return headersMap
-}
-
-// Gets header string by key
-func (c *Header) Get(key string) string {
-	return c.client().res.Header.Get(key)
-}
-
 // Delete header by key
 func (c *Header) Del(key string) *HTTPClient {
 	c.Client.request.header.Del(key)
 	return c.client()
 }
 
-// Sets header key with string value
+// Sets header key with string value, replacing the client default
 func (c *Header) Set(key string, value string) *HTTPClient {
-	c.Client.request.header.Add(key, value)
+	c.Client.request.header.Set(key, value)
 	return c.client()
 }
 
@@ -60,3 +42,33 @@ func (c *Header) Add(header *map[string]string) *HTTPClient {
 	}
 	return c.client()
 }
+
+// Init response header
+func (r *Response) Header() *ResponseHeader {
+	return &ResponseHeader{Response: r}
+}
+
+// Gets raw http.Header from response
+func (c *ResponseHeader) get() http.Header {
+	if c.Response.raw == nil {
+		return http.Header{}
+	}
+	return c.Response.raw.Header
+}
+
+// Gets header and convert in string map
+func (c *ResponseHeader) GetWithStringMap() map[string]string {
+	var headersMap = make(map[string]string)
+	headers := c.get()
+	for key, values := range headers {
+		for _, value := range values {
+			headersMap[fmt.Sprintf("%s", key)] = fmt.Sprintf("%s", value)
+		}
+	}
+	return headersMap
+}
+
+// Gets header string by key
+func (c *ResponseHeader) Get(key string) string {
+	return c.get().Get(key)
+}
@@ -0,0 +1,101 @@
+package grequest
+
+import (
+	"net/http"
+	"slices"
+)
+
+// HookEvent describes a step of a request passed to the hooks
+type HookEvent struct {
+	// Request of the step, nil for an error of building the request
+	Request *http.Request
+	// Response of the step, nil when there is none
+	Response *http.Response
+	// Attempt of the request to the current url starting from 1, the number
+	// of the hop for a redirect and the count of all attempts for an error
+	Attempt int
+	Err     error
+}
+
+// Hook is called on a step of a request
+type Hook func(e HookEvent)
+
+// VetoHook is called before a retry or a redirect, false cancels it
+type VetoHook func(e HookEvent) bool
+
+// hooks registered on a client
+type hooks struct {
+	beforeRequest []Hook
+	afterResponse []Hook
+	onError       []Hook
+	onRetry       []VetoHook
+	onRedirect    []VetoHook
+}
+
+func (h hooks) clone() hooks {
+	return hooks{
+		beforeRequest: slices.Clone(h.beforeRequest),
+		afterResponse: slices.Clone(h.afterResponse),
+		onError:       slices.Clone(h.onError),
+		onRetry:       slices.Clone(h.onRetry),
+		onRedirect:    slices.Clone(h.onRedirect),
+	}
+}
+
+func runHooks(list []Hook, e HookEvent) {
+	for _, hook := range list {
+		hook(e)
+	}
+}
+
+// Runs all veto hooks, false when one of them cancels
+func runVetoHooks(list []VetoHook, e HookEvent) bool {
+	for _, hook := range list {
+		if !hook(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds hook called before every attempt is sent, redirect hops included
+func (c *Client) OnBeforeRequest(hook Hook) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.beforeRequest = append(c.hooks.beforeRequest, hook)
+	return c
+}
+
+// Adds hook called after every response is received, before the body is read
+func (c *Client) OnAfterResponse(hook Hook) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.afterResponse = append(c.hooks.afterResponse, hook)
+	return c
+}
+
+// Adds hook called once when a request fails after all retries and redirects
+func (c *Client) OnError(hook Hook) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onError = append(c.hooks.onError, hook)
+	return c
+}
+
+// Adds hook called before a retry with the failed attempt, false cancels the retry
+// and the failed attempt is the result
+func (c *Client) OnRetry(hook VetoHook) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onRetry = append(c.hooks.onRetry, hook)
+	return c
+}
+
+// Adds hook called with the next request and the redirect response before a
+// redirect is followed, false stops and the redirect response is the result
+func (c *Client) OnRedirect(hook VetoHook) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hooks.onRedirect = append(c.hooks.onRedirect, hook)
+	return c
+}
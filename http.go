@@ -5,41 +5,55 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"slices"
 	"sync"
 	"time"
 )
 
+// HTTPClient builds a single request. Builders are cheap, independent of each
+// other and are created from a shared Client, see Client.NewRequest.
 type HTTPClient struct {
-	cacheEnabled bool
-	errors       []error
-	maxRedirect  int
-	retryCodes   []int
-	maxRetries   int
-	Timeout      time.Duration
-	userAgent    string
-	client       *http.Client
-	transport    *http.Transport
-	cjar         *cookiejar.Jar
-	request      *Request
-	res          *http.Response
-	BodyBytes    []byte
-	errs         []error
+	maxRedirect    int
+	redirectPolicy RedirectPolicy
+	retry          RetryPolicy
+	Timeout        time.Duration
+	cache          CacheStorage
+	baseURL        *url.URL
+	stream         bool
+	// Progress callbacks of the request and response bodies
+	uploadProgress   ProgressFunc
+	downloadProgress ProgressFunc
+	progressInterval time.Duration
+	// Bandwidth caps of the client and of this request
+	clientBandwidth *BandwidthLimiter
+	bandwidth       *BandwidthLimiter
+	middlewares     []Middleware
+	hooks           hooks
+	rateLimits      *rateLimits
+	adaptive        *adaptiveLimits
+	breakers        *circuitBreakers
+	client          *Client
+	request         *Request
+	errs            []error
 }
 
 type Request struct {
 	req            *http.Request
 	header         http.Header
-	body           io.Reader
+	body           *requestBody
 	method         string
 	url            string
 	basic          *BasicAuth
+	pathParams     map[string]string
+	query          []queryOp
+	queryEncoder   valuesEncoder
+	arrayStyle     ArrayStyle
 	isRequestReady bool
-	isRequested    bool
 	Cookie         []*http.Cookie
 }
 
@@ -54,50 +68,16 @@ var (
 	client         *HTTPClient
 	once           sync.Once
 	timeoutDefault = 30 * time.Second
-	// Errors
-	ErrInvalidHost             = errors.New("Invalid Host Request")
-	ErrInvalidURL              = errors.New("Invalid URL")
-	ErrInvalidRedirectLocation = errors.New("Invalid Redirect Location")
-	ErrTooManyRedirection      = errors.New("Too many Redirect")
-	ErrTooManyRetry            = errors.New("Too many Retry")
 )
 
+// Init request builder with its own client
 func New() *HTTPClient {
-	jar, err := cookiejar.New(&cookiejar.Options{})
-
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 32,
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true,
-		},
-	}
-
-	client := &HTTPClient{
-		client: &http.Client{
-			Jar:       jar,
-			Transport: transport,
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse
-			},
-		},
-		transport: transport,
-		cjar:      jar,
-		request: &Request{
-			method:         http.MethodGet,
-			isRequestReady: false,
-			isRequested:    false,
-			header:         make(http.Header),
-		},
-		maxRedirect:  maxRedirectDefault,
-		cacheEnabled: false,
-	}
+	return NewClient().NewRequest()
+}
 
-	if err != nil {
-		client.errs[0] = err
-	}
-	client.request.header[userAgentField] = []string{userAgentName}
-	return client
+// Gets the client which this request belongs to
+func (c *HTTPClient) Client() *Client {
+	return c.client
 }
 
 func (c *HTTPClient) SetURL(u string) *HTTPClient {
@@ -105,12 +85,6 @@ func (c *HTTPClient) SetURL(u string) *HTTPClient {
 	return c
 }
 
-func (c *HTTPClient) Request() {
-	if !c.request.isRequested {
-		c.Do()
-	}
-}
-
 func (c *HTTPClient) SetUserAgent(agent string) *HTTPClient {
 	c.request.header.Set(userAgentField, agent)
 	return c
@@ -129,42 +103,57 @@ func (c *HTTPClient) SetRequest(req *http.Request) *HTTPClient {
 	return c
 }
 
+// Sets tls config of the underlying client, this affects all its requests
 func (c *HTTPClient) SetTLSConfig(config *tls.Config) *HTTPClient {
-	c.transport.TLSClientConfig = config
-	c.client.Transport = c.transport
+	c.client.SetTLSConfig(config)
 	return c
 }
 
-func (c *HTTPClient) newRequest(ctx context.Context) *HTTPClient {
-
-	parsedURL, err := checkURL(c.request.url)
+func (c *HTTPClient) newRequest(ctx context.Context) (*http.Request, error) {
+	if c.request.isRequestReady {
+		req := c.request.req.WithContext(ctx)
+		c.throttleUpload(req)
+		c.trackUpload(req)
+		return req, nil
+	}
 
+	rawURL, err := c.requestURL()
 	if err != nil {
-		c.errs = append(c.errs, err)
-		return c
+		return nil, err
 	}
+	parsedURL, err := checkURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	c.request.applyQuery(parsedURL)
 
-	c.request.url = parsedURL.String()
-	c.request.req, err = http.NewRequestWithContext(ctx, c.request.method, c.request.url, c.request.body)
-
+	req, err := http.NewRequestWithContext(ctx, c.request.method, parsedURL.String(), nil)
 	if err != nil {
-		c.errs = append(c.errs, err)
-		return c
+		return nil, err
+	}
+	if c.request.body != nil {
+		if !c.request.body.rewindable && c.retry.MaxRetries > 0 {
+			return nil, fmt.Errorf("%w: retries need a seekable body", ErrBodyNotRewindable)
+		}
+		if err := c.request.body.apply(req); err != nil {
+			return nil, err
+		}
 	}
 
-	c.request.req.Header = c.request.header
+	req.Header = c.request.header.Clone()
 
 	if c.request.basic != nil {
-		c.request.req.SetBasicAuth(c.request.basic.User, c.request.basic.Pass)
+		req.SetBasicAuth(c.request.basic.User, c.request.basic.Pass)
 	}
 	if c.request.Cookie != nil {
 		for _, cookie := range c.request.Cookie {
-			c.request.req.AddCookie(cookie)
+			req.AddCookie(cookie)
 		}
 	}
-	c.request.isRequestReady = true
+	c.throttleUpload(req)
+	c.trackUpload(req)
 
-	return c
+	return req, nil
 }
 
 func (c *HTTPClient) SetTimeout(timeout time.Duration) *HTTPClient {
@@ -177,162 +166,173 @@ func (c *HTTPClient) SetTimeoutSecons(second time.Duration) *HTTPClient {
 	return c
 }
 
-// Sets the conditions for retrying a http request
-func (c *HTTPClient) RetryIf(statusCodes ...int) *HTTPClient {
-	c.retryCodes = statusCodes
-	c.maxRetries = maxRetriesDefault
-	return c
-}
-
-// Sets the counts for retrying a http request
-func (c *HTTPClient) RetryMax(maxRetries int) *HTTPClient {
-	c.maxRetries = maxRetries
+// Makes Do return as soon as headers arrive, the body is read from the network
+// on demand and the response must be closed, see Response.Close
+func (c *HTTPClient) Stream() *HTTPClient {
+	c.stream = true
 	return c
 }
 
 // Makes a request to the http server
-func (c *HTTPClient) Do() *HTTPClient {
-	timeoutRequest := c.Timeout
-	if timeoutRequest == 0 {
-		timeoutRequest = timeoutDefault
+func (c *HTTPClient) Do() *Response {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout())
+	response := c.DoWithContext(ctx)
+	if response.stream == nil {
+		cancel()
+		return response
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), timeoutRequest)
-	defer cancel()
-	return c.newRequest(ctx).do()
+	// Streamed body is still read after Do returns, so the context lives until Close
+	response.cancel = cancel
+	return response
 }
 
-func (c *HTTPClient) DoWithContext(ctx context.Context) *HTTPClient {
-	return c.newRequest(ctx).do()
+// Gets timeout of the request or the default one
+func (c *HTTPClient) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return timeoutDefault
+	}
+	return c.Timeout
 }
 
-func (c *HTTPClient) do() *HTTPClient {
-
-	var res *http.Response
-	var err error
-	res, err = c.client.Do(c.request.req)
-	if err != nil {
-		c.errs = append(c.errs, err)
-		return c
-	}
-	defer c.Close()
-	status := res.StatusCode
-	if c.maxRetries > 0 && slices.Contains(c.retryCodes, status) {
-		res, err = c.retryRequest(c.request.req, res, 0)
-		if err != nil {
-			c.res = res
-			c.errs = append(c.errs, err)
-		}
+// Makes a request with the context or, when ctx is nil, with the request timeout
+// bounding the response headers only, so a long body is read without a deadline
+func (c *HTTPClient) doContext(ctx context.Context) *Response {
+	if ctx == nil {
+		return c.doHeaderTimeout(context.Background())
 	}
+	return c.DoWithContext(ctx)
+}
 
-	if c.maxRedirect > 0 && status != 300 && status/100 == 3 {
-		res, err = c.redirectRequest(c.request.req, res, 0)
-		if err != nil {
-			c.res = res
-			c.errs = append(c.errs, err)
-		}
+// Makes a request canceled when its response headers are not read within the
+// request timeout, a streamed body is read until Close
+func (c *HTTPClient) doHeaderTimeout(ctx context.Context) *Response {
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(c.timeout(), cancel)
+	response := c.DoWithContext(ctx)
+	timer.Stop()
+	if response.stream == nil {
+		cancel()
+		return response
 	}
-	if res.Header.Get("Content-Encoding") == "gzip" {
-		var gres io.ReadCloser
-		gres, err = gzip.NewReader(res.Body)
-		if err != nil {
-			c.res = res
-			c.errs = append(c.errs, err)
-			c.request.isRequested = true
-			return c
-		}
-		res.Body = gres
-	}
-	bodyBytes, _ := io.ReadAll(res.Body)
-	c.BodyBytes = bodyBytes
-	c.res = res
-	c.request.isRequested = true
-
-	return c
+	response.cancel = cancel
+	return response
 }
 
-func (c *HTTPClient) retryRequest(req *http.Request, res *http.Response, count int) (*http.Response, error) {
-	if count <= c.maxRetries {
-		retryRes, err := c.client.Transport.RoundTrip(req)
-		if err != nil {
-			c.res = retryRes
-			c.errs = append(c.errs, err)
+func (c *HTTPClient) DoWithContext(ctx context.Context) *Response {
+	response := &Response{errs: slices.Clone(c.errs)}
+	start := time.Now()
+	var req *http.Request
+	defer func() {
+		response.elapsed = time.Since(start)
+		if len(response.errs) > 0 {
+			runHooks(c.hooks.onError, HookEvent{Request: req, Response: response.raw, Attempt: response.attempts, Err: response.Err()})
 		}
-		if slices.Contains(c.retryCodes, res.StatusCode) {
-			return c.retryRequest(req, retryRes, count+1)
-		}
-		return retryRes, nil
+	}()
+	req, err := c.newRequest(ctx)
+	if err != nil {
+		response.errs = append(response.errs, c.newError(PhaseBuild, err))
+		return response
 	}
-	return res, ErrTooManyRetry
+	response.request = req
+	response.finalURL = req.URL
+	return c.do(req, response)
 }
 
-func (c *HTTPClient) redirectRequest(req *http.Request, res *http.Response, count int) (redirectRes *http.Response, err error) {
-
-	if count > c.maxRedirect {
-		return res, ErrTooManyRedirection
+func (c *HTTPClient) do(req *http.Request, response *Response) *Response {
+	httpClient := c.client.httpClient()
+	res, err := c.fetch(httpClient, req, response)
+	if err == nil && c.maxRedirect > 0 && isRedirect(res.StatusCode) {
+		res, err = c.redirectRequest(httpClient, req, res, response)
 	}
-	redirectReq := req
-	loc := res.Header.Get("Location")
-	if len(loc) == 0 {
-		return res, ErrInvalidRedirectLocation
+	if res == nil {
+		response.addError(errorPhase(err), err)
+		return response
 	}
-	var redirectToUrl *url.URL
-	urlLoc, err := checkURL(loc)
-	redirectToUrl = urlLoc
 	if err != nil {
-		toLoc, _ := url.ParseRequestURI(req.URL.String() + loc)
-		redirectToUrl = toLoc
+		response.addError(errorPhase(err), err)
 	}
-
-	redirectReq.URL = redirectToUrl
-	redirectRes, err = c.client.Transport.RoundTrip(redirectReq)
-	if err == nil {
-		switch redirectRes.StatusCode / 100 {
-		case 2:
-			return redirectRes, nil
-		case 3:
-			return c.redirectRequest(redirectReq, redirectRes, count+1)
-		case 4, 5:
-			return redirectRes, errors.New(http.StatusText(redirectRes.StatusCode))
+	response.raw = res
+	var body io.Reader = res.Body
+	if !response.fromCache {
+		body = c.throttleDownload(req.Context(), body)
+	}
+	body = c.trackDownload(res, body)
+	if res.Header.Get("Content-Encoding") == "gzip" {
+		body, err = gzip.NewReader(body)
+		if err != nil {
+			res.Body.Close()
+			response.addError(PhaseDecode, err)
+			return response
 		}
 	}
-	return res, err
-}
-
-func (c *HTTPClient) response() *http.Response {
-	return c.res
-}
+	if c.stream {
+		response.stream = &streamBody{Reader: body, Closer: res.Body}
+		return response
+	}
+	defer res.Body.Close()
+	response.BodyBytes, err = io.ReadAll(body)
+	if err != nil {
+		response.addError(PhaseRead, err)
+	}
 
-func (c *HTTPClient) requests() *http.Request {
-	return c.request.req
+	return response
 }
 
-func (c *HTTPClient) GetResponse() (*http.Response, []error) {
-	if !c.request.isRequested {
-		c.Do()
-	}
-	return c.res, c.errs
+// Gets a copy of the builder which can be changed without affecting this one
+func (c *HTTPClient) clone() *HTTPClient {
+	clone := *c
+	request := *c.request
+	request.header = c.request.header.Clone()
+	request.Cookie = slices.Clone(c.request.Cookie)
+	request.query = slices.Clone(c.request.query)
+	request.pathParams = maps.Clone(c.request.pathParams)
+	clone.request = &request
+	clone.retry = c.retry.clone()
+	clone.errs = slices.Clone(c.errs)
+	clone.middlewares = slices.Clone(c.middlewares)
+	clone.hooks = c.hooks.clone()
+	return &clone
 }
 
 func (c *HTTPClient) GetErrors() []error {
 	return c.errs
 }
 
-func (c *HTTPClient) ResetClient() *HTTPClient {
-	return New()
+// Gets all errors collected while building the request joined into one
+func (c *HTTPClient) Err() error {
+	return errors.Join(c.errs...)
 }
 
-func (c *HTTPClient) Close() []error {
-	err := c.res.Body.Close()
-	if err != nil {
-		c.errs = append(c.errs, err)
+func (c *HTTPClient) addError(phase Phase, err error) {
+	c.errs = append(c.errs, c.newError(phase, err))
+}
+
+func (c *HTTPClient) newError(phase Phase, err error) *RequestError {
+	return &RequestError{
+		Phase:  phase,
+		Method: c.request.method,
+		URL:    c.request.url,
+		Err:    err,
 	}
-	errs := c.errs
-	c = nil
-	return errs
 }
 
+// Gets new request builder on the same client, cookies and connections are kept
+func (c *HTTPClient) ResetClient() *HTTPClient {
+	return c.client.NewRequest()
+}
+
+// Gets url the request is sent to, the url as it was set when path params
+// are missing and an empty url when it can not be parsed at all
 func (c *HTTPClient) GetCurrentUrl() *url.URL {
-	parsedURL, _ := url.Parse(c.request.url)
+	rawURL, err := c.requestURL()
+	if err != nil {
+		rawURL = c.request.url
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return &url.URL{}
+	}
+	c.request.applyQuery(parsedURL)
 	return parsedURL
 }
 
@@ -82,3 +82,33 @@ func (c *HTTPClient) Head(u string) *HTTPClient {
 	c.request.url = u
 	return c
 }
+
+// Init get http method on the client
+func (c *Client) Get(u string) *HTTPClient {
+	return c.NewRequest().Get(u)
+}
+
+// Init Post http method on the client
+func (c *Client) Post(u string) *HTTPClient {
+	return c.NewRequest().Post(u)
+}
+
+// Init Put http method on the client
+func (c *Client) Put(u string) *HTTPClient {
+	return c.NewRequest().Put(u)
+}
+
+// Init Patch http method on the client
+func (c *Client) Patch(u string) *HTTPClient {
+	return c.NewRequest().Patch(u)
+}
+
+// Init Delete http method on the client
+func (c *Client) Delete(u string) *HTTPClient {
+	return c.NewRequest().Delete(u)
+}
+
+// Init Head http method on the client
+func (c *Client) Head(u string) *HTTPClient {
+	return c.NewRequest().Head(u)
+}
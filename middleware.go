@@ -0,0 +1,59 @@
+package grequest
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Handler sends a request and gets its response
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps every round trip of a request, retries and redirect hops
+// included. It may change the request before calling next, inspect the
+// response after it or return a response without calling next at all.
+type Middleware func(next Handler) Handler
+
+// Adds middlewares to all new requests, they run in the order they are added
+// and before the middlewares of the request
+func (c *Client) Use(middlewares ...Middleware) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.middlewares = append(c.middlewares, middlewares...)
+	return c
+}
+
+// Adds middlewares to the request, they run in the order they are added
+// and after the middlewares of the client
+func (c *HTTPClient) Use(middlewares ...Middleware) *HTTPClient {
+	c.middlewares = append(slices.Clip(c.middlewares), middlewares...)
+	return c
+}
+
+// Gets handler which sends a request through all middlewares and then the client,
+// the first middleware is the outermost one
+func (c *HTTPClient) handler(httpClient *http.Client) Handler {
+	next := Handler(httpClient.Do)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		next = c.middlewares[i](next)
+	}
+	if len(c.middlewares) == 0 {
+		return next
+	}
+	return func(req *http.Request) (*http.Response, error) {
+		res, err := next(req)
+		if err != nil {
+			return res, err
+		}
+		if res == nil {
+			return nil, fmt.Errorf("%w: middleware returned neither response nor error", ErrNoResponse)
+		}
+		if res.Body == nil {
+			res.Body = http.NoBody
+		}
+		if res.Request == nil {
+			res.Request = req
+		}
+		return res, nil
+	}
+}
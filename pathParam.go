@@ -0,0 +1,109 @@
+package grequest
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Sets base url which relative urls of new requests are resolved against,
+// like "https://api.example.com/v1" and "/users" giving "https://api.example.com/v1/users"
+func (c *Client) SetBaseURL(baseURL string) *Client {
+	u, err := checkURL(baseURL)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		c.errs = append(c.errs, &RequestError{Phase: PhaseBuild, Err: fmt.Errorf("base url %q: %w", baseURL, err)})
+		return c
+	}
+	u.RawQuery = ""
+	u.Fragment = ""
+	c.baseURL = u
+	return c
+}
+
+// Sets value of a {name} placeholder of the url, the value is escaped
+func (c *HTTPClient) PathParam(name, value string) *HTTPClient {
+	if c.request.pathParams == nil {
+		c.request.pathParams = make(map[string]string)
+	}
+	c.request.pathParams[name] = value
+	return c
+}
+
+// Sets values of {name} placeholders of the url from map
+func (c *HTTPClient) PathParams(params map[string]string) *HTTPClient {
+	for name, value := range params {
+		c.PathParam(name, value)
+	}
+	return c
+}
+
+// Gets url of the request with path params filled in and resolved against the base url
+func (c *HTTPClient) requestURL() (string, error) {
+	u, err := fillPathParams(c.request.url, c.request.pathParams)
+	if err != nil {
+		return "", err
+	}
+	return resolveURL(c.baseURL, u), nil
+}
+
+// Replaces {name} placeholders in the path with escaped values, a placeholder
+// without value is an error. Braces in the query and fragment are kept.
+func fillPathParams(template string, params map[string]string) (string, error) {
+	var buf strings.Builder
+	rest, tail := template, ""
+	if i := strings.IndexAny(template, "?#"); i >= 0 {
+		rest, tail = template[:i], template[i:]
+	}
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			break
+		}
+		name := rest[start+1 : start+end]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrMissingPathParam, name)
+		}
+		buf.WriteString(rest[:start])
+		buf.WriteString(escapePathParam(value))
+		rest = rest[start+end+1:]
+	}
+	buf.WriteString(rest)
+	buf.WriteString(tail)
+	return buf.String(), nil
+}
+
+// Escapes a value so it stays a single path segment
+func escapePathParam(value string) string {
+	switch value {
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	return url.PathEscape(value)
+}
+
+// Joins a relative url to the base one, absolute urls are kept
+func resolveURL(base *url.URL, ref string) string {
+	if base == nil {
+		return ref
+	}
+	if u, err := url.Parse(ref); err != nil || u.IsAbs() || u.Host != "" {
+		return ref
+	}
+	joined := strings.TrimSuffix(base.String(), "/")
+	switch {
+	case ref == "":
+		return joined
+	case strings.HasPrefix(ref, "?"), strings.HasPrefix(ref, "#"):
+		return joined + ref
+	}
+	return joined + "/" + strings.TrimPrefix(ref, "/")
+}
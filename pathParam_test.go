@@ -0,0 +1,84 @@
+package grequest
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestResolveURL(t *testing.T) {
+	base, _ := url.Parse("https://api.example.com/v1/")
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"", "https://api.example.com/v1"},
+		{"/users", "https://api.example.com/v1/users"},
+		{"users/1", "https://api.example.com/v1/users/1"},
+		{"?page=2", "https://api.example.com/v1?page=2"},
+		{"/login?next=https://example.com/x", "https://api.example.com/v1/login?next=https://example.com/x"},
+		{"https://other.example.com/x", "https://other.example.com/x"},
+		{"//other.example.com/x", "//other.example.com/x"},
+	}
+	for _, tt := range tests {
+		if got := resolveURL(base, tt.ref); got != tt.want {
+			t.Errorf("resolveURL(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+	if got := resolveURL(nil, "/users"); got != "/users" {
+		t.Errorf("resolveURL without base = %q", got)
+	}
+}
+
+func TestBaseURLRequest(t *testing.T) {
+	client := NewClient().SetBaseURL("https://api.example.com/v1")
+	req := client.Get("/login?next=https://example.com/x")
+	if err := req.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.GetCurrentUrl().String(); got != "https://api.example.com/v1/login?next=https://example.com/x" {
+		t.Fatalf("url = %q", got)
+	}
+}
+
+func TestFillPathParams(t *testing.T) {
+	params := map[string]string{"id": "a b/c", "dot": ".."}
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"https://h/users/{id}", "https://h/users/a%20b%2Fc"},
+		{"/files/{dot}/x", "/files/%2E%2E/x"},
+		{"/users/{id}?fields={id}", "/users/a%20b%2Fc?fields={id}"},
+		{`https://h/search?filter={"a":1}`, `https://h/search?filter={"a":1}`},
+		{"/page#{section}", "/page#{section}"},
+	}
+	for _, tt := range tests {
+		got, err := fillPathParams(tt.template, params)
+		if err != nil || got != tt.want {
+			t.Errorf("fillPathParams(%q) = %q, %v, want %q", tt.template, got, err, tt.want)
+		}
+	}
+	if _, err := fillPathParams("/users/{name}", params); !errors.Is(err, ErrMissingPathParam) {
+		t.Errorf("err = %v, want missing path param", err)
+	}
+}
+
+func TestBracesInQueryWithoutPathParams(t *testing.T) {
+	req := NewClient().Get(`https://h/search?filter={"a":1}`)
+	if err := req.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.GetCurrentUrl().Query().Get("filter"); got != `{"a":1}` {
+		t.Fatalf("filter = %q", got)
+	}
+}
+
+func TestCurrentUrlWithMissingPathParam(t *testing.T) {
+	req := NewClient().Get("http://example.com/users/{id}")
+	if got := req.GetCurrentUrl().Host; got != "example.com" {
+		t.Fatalf("host = %q", got)
+	}
+	// Cookies are kept per host even before the path is complete
+	req.Cookie().Load()
+}
@@ -0,0 +1,188 @@
+package grequest
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Progress is a snapshot of a body transfer
+type Progress struct {
+	// Bytes transferred so far, a resumed download starts from its offset
+	Transferred int64
+	// Size of the body, -1 when unknown
+	Total int64
+	// Average speed in bytes per second
+	Rate    float64
+	Elapsed time.Duration
+	// Set on the last call when the body is complete
+	Done bool
+}
+
+// ProgressFunc receives progress of a transfer, calls are never concurrent
+type ProgressFunc func(Progress)
+
+const progressIntervalDefault = 200 * time.Millisecond
+
+// progressMeter counts bytes of one transfer, it may be shared by several readers
+type progressMeter struct {
+	mu          sync.Mutex
+	fn          ProgressFunc
+	interval    time.Duration
+	start       time.Time
+	last        time.Time
+	offset      int64
+	transferred int64
+	total       int64
+	done        bool
+}
+
+// progressReader reports bytes read through it to the meter
+type progressReader struct {
+	io.Reader
+	meter *progressMeter
+	// One of several readers of the meter, EOF does not complete the transfer
+	part bool
+}
+
+// progressReadCloser is a progressReader of a request body
+type progressReadCloser struct {
+	progressReader
+	closer io.Closer
+}
+
+func newProgressMeter(fn ProgressFunc, interval time.Duration, total int64) *progressMeter {
+	if interval <= 0 {
+		interval = progressIntervalDefault
+	}
+	now := time.Now()
+	return &progressMeter{fn: fn, interval: interval, start: now, last: now, total: total}
+}
+
+// Starts the count from offset, used when a transfer is resumed
+func (m *progressMeter) resume(offset int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.offset = offset
+	m.transferred = offset
+}
+
+func (m *progressMeter) add(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done {
+		return
+	}
+	m.transferred += n
+	if m.total >= 0 && m.transferred >= m.total {
+		m.done = true
+		m.report()
+		return
+	}
+	if time.Since(m.last) >= m.interval {
+		m.report()
+	}
+}
+
+func (m *progressMeter) finish() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.done {
+		return
+	}
+	m.done = true
+	m.report()
+}
+
+func (m *progressMeter) report() {
+	now := time.Now()
+	m.last = now
+	elapsed := now.Sub(m.start)
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(m.transferred-m.offset) / elapsed.Seconds()
+	}
+	m.fn(Progress{
+		Transferred: m.transferred,
+		Total:       m.total,
+		Rate:        rate,
+		Elapsed:     elapsed,
+		Done:        m.done,
+	})
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.meter.add(int64(n))
+	}
+	if err == io.EOF && !r.part {
+		r.meter.finish()
+	}
+	return n, err
+}
+
+func (r *progressReadCloser) Close() error {
+	return r.closer.Close()
+}
+
+// Wraps the request body so reading it reports upload progress,
+// every resend of the body starts a new count
+func (c *HTTPClient) trackUpload(req *http.Request) {
+	if c.uploadProgress == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	total := req.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+	wrap := func(body io.ReadCloser) io.ReadCloser {
+		meter := newProgressMeter(c.uploadProgress, c.progressInterval, total)
+		return &progressReadCloser{progressReader: progressReader{Reader: body, meter: meter}, closer: body}
+	}
+	req.Body = wrap(req.Body)
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return wrap(body), nil
+		}
+	}
+}
+
+// Wraps the response body so reading it reports download progress,
+// a partial response is counted from its first byte of the whole resource
+func (c *HTTPClient) trackDownload(res *http.Response, body io.Reader) io.Reader {
+	if c.downloadProgress == nil {
+		return body
+	}
+	meter := newProgressMeter(c.downloadProgress, c.progressInterval, res.ContentLength)
+	if res.StatusCode == http.StatusPartialContent {
+		if start, total, ok := parseContentRange(res.Header.Get(contentRange)); ok && start >= 0 {
+			meter.total = total
+			meter.resume(start)
+		}
+	}
+	return &progressReader{Reader: body, meter: meter}
+}
+
+// Sets callback reporting how much of the request body is sent
+func (c *HTTPClient) OnUploadProgress(fn ProgressFunc) *HTTPClient {
+	c.uploadProgress = fn
+	return c
+}
+
+// Sets callback reporting how much of the response body is received
+func (c *HTTPClient) OnDownloadProgress(fn ProgressFunc) *HTTPClient {
+	c.downloadProgress = fn
+	return c
+}
+
+// Sets min time between two progress calls, 200ms by default
+func (c *HTTPClient) ProgressInterval(interval time.Duration) *HTTPClient {
+	c.progressInterval = interval
+	return c
+}
@@ -1,9 +1,5 @@
 package grequest
 
-import (
-	"net/http"
-)
-
 type Proxy struct {
 	Client *HTTPClient
 }
@@ -12,13 +8,12 @@ func (c *HTTPClient) Proxy() *Proxy {
 	return &Proxy{Client: c}
 }
 
+// Sets proxy of the underlying client, this affects all its requests
 func (c *Proxy) SetProxy(uri string) *HTTPClient {
-	u, err := checkURL(uri)
-	if err != nil {
-		c.Client.errs = append(c.Client.errs, err)
+	if _, err := checkURL(uri); err != nil {
+		c.Client.addError(PhaseBuild, err)
 		return c.Client
 	}
-	c.Client.transport.Proxy = http.ProxyURL(u)
-	c.Client.client.Transport = c.Client.transport
+	c.Client.client.SetProxy(uri)
 	return c.Client
 }
@@ -0,0 +1,209 @@
+package grequest
+
+import (
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ArrayStyle selects how a key with several values is written to a query string
+type ArrayStyle int
+
+const (
+	// a=1&a=2
+	ArrayRepeat ArrayStyle = iota
+	// a[]=1&a[]=2
+	ArrayBrackets
+	// a=1,2
+	ArrayComma
+)
+
+type Query struct {
+	Client *HTTPClient
+}
+
+// queryOp is a change of the query string, applied to the query of the url
+// when the request is built
+type queryOp struct {
+	key    string
+	values []string
+	// Replace values already in the url
+	replace bool
+	// Add only when the url has no value of the key
+	missing bool
+	// The key is an array even with a single value
+	array bool
+}
+
+// Init query of the request
+func (c *HTTPClient) Query() *Query {
+	return &Query{Client: c}
+}
+
+// Gets http client object
+func (c *Query) client() *HTTPClient {
+	return c.Client
+}
+
+// Sets query parameter, values already in the url are replaced
+func (c *Query) Set(key, value string) *HTTPClient {
+	return c.push(queryOp{key: key, values: []string{value}, replace: true})
+}
+
+// Adds value to query parameter, values already in the url are kept.
+// The parameter is an array and gets brackets with ArrayBrackets.
+func (c *Query) Add(key, value string) *HTTPClient {
+	return c.push(queryOp{key: key, values: []string{value}, array: true})
+}
+
+// Deletes query parameter, also the one in the url
+func (c *Query) Del(key string) *HTTPClient {
+	return c.push(queryOp{key: key, replace: true})
+}
+
+// Sets query parameters from url.Values, a map or a struct with form tags,
+// see EncodeValues. Values already in the url are replaced.
+func (c *Query) SetValues(data interface{}) *HTTPClient {
+	return c.pushValues(data, true)
+}
+
+// Adds query parameters from url.Values, a map or a struct with form tags,
+// see EncodeValues. Values already in the url are kept.
+func (c *Query) AddValues(data interface{}) *HTTPClient {
+	return c.pushValues(data, false)
+}
+
+// Sets how a key with several values is written, a=1&a=2 by default
+func (c *Query) SetArrayStyle(style ArrayStyle) *HTTPClient {
+	c.client().request.arrayStyle = style
+	return c.client()
+}
+
+// Sets how keys of nested structs and maps are joined, brackets by default
+func (c *Query) SetNestedStyle(style NestedStyle) *HTTPClient {
+	c.client().request.queryEncoder.nested = style
+	return c.client()
+}
+
+func (c *Query) push(op queryOp) *HTTPClient {
+	c.client().request.query = append(c.client().request.query, op)
+	return c.client()
+}
+
+func (c *Query) pushValues(data interface{}, replace bool) *HTTPClient {
+	encoder := c.client().request.queryEncoder
+	encoder.arrays = make(map[string]bool)
+	values, err := encoder.encode(data)
+	if err != nil {
+		c.client().addError(PhaseBuild, err)
+		return c.client()
+	}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		c.push(queryOp{key: key, values: values[key], replace: replace, array: encoder.arrays[key]})
+	}
+	return c.client()
+}
+
+// Applies query changes of the request to the url, an url without changes is kept as is.
+// Pairs of keys without changes are kept as they were written, pairs of changed keys
+// are written again after them with the array style.
+func (r *Request) applyQuery(u *url.URL) {
+	if len(r.query) == 0 {
+		return
+	}
+	changed := make(map[string]bool)
+	for _, op := range r.query {
+		changed[op.key] = true
+	}
+	pairs := strings.Split(u.RawQuery, "&")
+	values := make(url.Values)
+	// Keys having a pair with a value that can not be unescaped
+	written := make(map[string]bool)
+	for _, pair := range pairs {
+		key, value, ok := splitQueryPair(pair)
+		if !changed[key] {
+			continue
+		}
+		if ok {
+			values.Add(key, value)
+		} else {
+			written[key] = true
+		}
+	}
+	replaced := make(map[string]bool)
+	arrays := make(map[string]bool)
+	for _, op := range r.query {
+		if op.missing && (values.Has(op.key) || written[op.key] && !replaced[op.key]) {
+			continue
+		}
+		if op.replace {
+			values.Del(op.key)
+			delete(arrays, op.key)
+			replaced[op.key] = true
+		}
+		for _, value := range op.values {
+			values.Add(op.key, value)
+		}
+		if op.array {
+			arrays[op.key] = true
+		}
+	}
+	var kept []string
+	for _, pair := range pairs {
+		key, _, ok := splitQueryPair(pair)
+		if pair != "" && (!changed[key] || !ok && !replaced[key]) {
+			kept = append(kept, pair)
+		}
+	}
+	if query := encodeQuery(values, r.arrayStyle, arrays); query != "" {
+		kept = append(kept, query)
+	}
+	u.RawQuery = strings.Join(kept, "&")
+}
+
+// Unescapes key and value of a query pair, ok is false when the value can not be
+// unescaped. A key that can not be unescaped is returned as it is.
+func splitQueryPair(pair string) (key, value string, ok bool) {
+	rawKey, rawValue, _ := strings.Cut(pair, "=")
+	key, err := url.QueryUnescape(rawKey)
+	if err != nil {
+		return rawKey, "", false
+	}
+	value, err = url.QueryUnescape(rawValue)
+	return key, value, err == nil
+}
+
+// Encodes values sorted by key like url.Values.Encode with the array style
+// applied to keys with several values and to the keys in arrays
+func encodeQuery(values url.Values, style ArrayStyle, arrays map[string]bool) string {
+	var buf strings.Builder
+	write := func(name, value string) {
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(value)
+	}
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		list := values[key]
+		name := key
+		if (len(list) > 1 || arrays[key]) && style == ArrayBrackets {
+			name += "[]"
+		}
+		name = url.QueryEscape(name)
+		escaped := make([]string, len(list))
+		for i, value := range list {
+			escaped[i] = url.QueryEscape(value)
+		}
+		if len(list) > 1 && style == ArrayComma {
+			write(name, strings.Join(escaped, ","))
+			continue
+		}
+		for _, value := range escaped {
+			write(name, value)
+		}
+	}
+	return buf.String()
+}
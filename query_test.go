@@ -0,0 +1,69 @@
+package grequest
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEncodeQuery(t *testing.T) {
+	values := url.Values{"a": {"1", "2"}, "b": {"x y"}, "c": {"3"}}
+	arrays := map[string]bool{"c": true}
+	tests := []struct {
+		style ArrayStyle
+		want  string
+	}{
+		{ArrayRepeat, "a=1&a=2&b=x+y&c=3"},
+		{ArrayBrackets, "a%5B%5D=1&a%5B%5D=2&b=x+y&c%5B%5D=3"},
+		{ArrayComma, "a=1,2&b=x+y&c=3"},
+	}
+	for _, tt := range tests {
+		if got := encodeQuery(values, tt.style, arrays); got != tt.want {
+			t.Errorf("encodeQuery(style %d) = %q, want %q", tt.style, got, tt.want)
+		}
+	}
+}
+
+func TestQueryArrayBrackets(t *testing.T) {
+	req := NewClient().Get("https://h/items?page=1")
+	req.Query().SetValues(struct {
+		IDs  []int    `form:"ids"`
+		Tags []string `form:"tags"`
+		Sort string   `form:"sort"`
+	}{IDs: []int{1}, Tags: []string{"a", "b"}, Sort: "name"})
+	req.Query().Add("label", "x")
+	req.Query().SetArrayStyle(ArrayBrackets)
+	want := "page=1&ids%5B%5D=1&label%5B%5D=x&sort=name&tags%5B%5D=a&tags%5B%5D=b"
+	if got := req.GetCurrentUrl().RawQuery; got != want {
+		t.Fatalf("query = %q, want %q", got, want)
+	}
+}
+
+func TestQueryMerge(t *testing.T) {
+	req := NewClient().SetQueryParam("v", "2").Get("https://h/items?a=1&b=2&v=1")
+	req.Query().Set("a", "3").Query().Add("b", "4").Query().Del("c")
+	if got := req.GetCurrentUrl().RawQuery; got != "a=3&b=2&b=4&v=1" {
+		t.Fatalf("query = %q", got)
+	}
+}
+
+func TestQueryKeepsUnchangedPairs(t *testing.T) {
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"https://h/?q=a%20b&z=1;y=2", "q=a%20b&z=1;y=2&k=v"},
+		{"https://h/?bad=%zz&k=1", "bad=%zz&k=v"},
+		{"https://h/?k=%zz&x=1", "x=1&k=v"},
+		{"https://h/", "k=v"},
+	}
+	for _, tt := range tests {
+		req := NewClient().Get(tt.rawURL).Query().Set("k", "v")
+		if got := req.GetCurrentUrl().RawQuery; got != tt.want {
+			t.Errorf("query of %q = %q, want %q", tt.rawURL, got, tt.want)
+		}
+	}
+	req := NewClient().SetQueryParam("k", "v").Get("https://h/?k=%zz")
+	if got := req.GetCurrentUrl().RawQuery; got != "k=%zz" {
+		t.Errorf("default param over an unescaped value = %q", got)
+	}
+}
@@ -0,0 +1,213 @@
+package grequest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenBucket refills at rate tokens per second up to burst, a caller takes
+// tokens in advance and waits until the bucket is not in debt anymore
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter allows a number of requests per second with bursts,
+// one limiter may be shared by any number of concurrent requests
+type RateLimiter struct {
+	bucket *tokenBucket
+}
+
+// rateLimits are the request rate limits of a client
+type rateLimits struct {
+	mu     sync.Mutex
+	global *RateLimiter
+	// Limits set for single hosts
+	hosts map[string]*RateLimiter
+	// Limits created for every other host with hostRate and hostBurst
+	perHost   map[string]*RateLimiter
+	hostRate  float64
+	hostBurst int
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: time.Now()}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.rate, b.burst)
+	b.last = now
+}
+
+// Takes n tokens and gets the delay until they are allowed,
+// concurrent callers are served in order of their calls
+func (b *tokenBucket) reserve(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// Gives back tokens of a reservation which was not used
+func (b *tokenBucket) cancel(n float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.tokens = min(b.tokens+n, b.burst)
+}
+
+func (b *tokenBucket) setRate(rate, burst float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.rate = rate
+	b.burst = burst
+	b.tokens = min(b.tokens, burst)
+}
+
+// Init limiter of perSecond requests, burst requests may be sent at once
+func NewRateLimiter(perSecond float64, burst int) *RateLimiter {
+	perSecond, burstTokens := rateLimitParams(perSecond, burst)
+	return &RateLimiter{bucket: newTokenBucket(perSecond, burstTokens)}
+}
+
+func rateLimitParams(perSecond float64, burst int) (float64, float64) {
+	return max(perSecond, 1e-9), float64(max(burst, 1))
+}
+
+// Sets the limit, affects requests already waiting for it only partly
+func (l *RateLimiter) SetLimit(perSecond float64, burst int) *RateLimiter {
+	l.bucket.setRate(rateLimitParams(perSecond, burst))
+	return l
+}
+
+// Waits until a request is allowed or the context is done, a wait which
+// would end after the deadline of the context fails at once
+func (l *RateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	delay := l.bucket.reserve(1)
+	if delay == 0 {
+		return 0, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		l.bucket.cancel(1)
+		return 0, fmt.Errorf("%w: rate limit wait of %s exceeds the deadline", context.DeadlineExceeded, delay)
+	}
+	start := time.Now()
+	if err := sleepContext(ctx, delay); err != nil {
+		l.bucket.cancel(1)
+		return time.Since(start), err
+	}
+	return delay, nil
+}
+
+// Gets limiters which apply to the host, the global one first
+func (r *rateLimits) limiters(host string) []*RateLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var limiters []*RateLimiter
+	if r.global != nil {
+		limiters = append(limiters, r.global)
+	}
+	if limiter, ok := r.hosts[host]; ok {
+		return append(limiters, limiter)
+	}
+	if r.hostRate <= 0 {
+		return limiters
+	}
+	limiter, ok := r.perHost[host]
+	if !ok {
+		limiter = NewRateLimiter(r.hostRate, r.hostBurst)
+		r.perHost[host] = limiter
+	}
+	return append(limiters, limiter)
+}
+
+// Waits for the rate limits of the request host before it is sent,
+// the time spent is added to the response
+func (c *HTTPClient) waitRateLimit(req *http.Request, response *Response) error {
+	var limiters []*RateLimiter
+	if c.rateLimits != nil {
+		limiters = c.rateLimits.limiters(req.URL.Host)
+	}
+	if c.adaptive != nil {
+		limiters = append(limiters, c.adaptive.host(req.URL.Host).limiter)
+	}
+	for _, limiter := range limiters {
+		waited, err := limiter.Wait(req.Context())
+		response.rateLimitWait += waited
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Gets rate limits of the client, created on first use
+func (c *Client) limits() *rateLimits {
+	if c.rateLimits == nil {
+		c.rateLimits = &rateLimits{
+			hosts:   make(map[string]*RateLimiter),
+			perHost: make(map[string]*RateLimiter),
+		}
+	}
+	return c.rateLimits
+}
+
+// Sets limit of requests per second shared by all hosts, 0 removes it
+func (c *Client) SetRateLimit(perSecond float64, burst int) *Client {
+	var limiter *RateLimiter
+	if perSecond > 0 {
+		limiter = NewRateLimiter(perSecond, burst)
+	}
+	return c.SetRateLimiter(limiter)
+}
+
+// Sets limiter of requests shared by all hosts, it may be shared with other clients
+func (c *Client) SetRateLimiter(limiter *RateLimiter) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limits := c.limits()
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+	limits.global = limiter
+	return c
+}
+
+// Sets limit of requests per second which every host gets on its own, 0 removes it
+func (c *Client) SetHostRateLimit(perSecond float64, burst int) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limits := c.limits()
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+	limits.hostRate = perSecond
+	limits.hostBurst = burst
+	clear(limits.perHost)
+	return c
+}
+
+// Sets limit of requests per second of one host like "api.example.com" or
+// "api.example.com:8443" instead of the limit every host gets, 0 removes it
+func (c *Client) SetRateLimitFor(host string, perSecond float64, burst int) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limits := c.limits()
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+	if perSecond <= 0 {
+		delete(limits.hosts, host)
+		return c
+	}
+	limits.hosts[host] = NewRateLimiter(perSecond, burst)
+	return c
+}
@@ -0,0 +1,79 @@
+package grequest
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Waits until the count of goroutines drops to max or fails the test
+func waitGoroutines(t *testing.T, max int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > max {
+		if time.Now().After(deadline) {
+			t.Fatalf("goroutines = %d, want at most %d", runtime.NumGoroutine(), max)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Gets a request with a streamed multipart body to an address never dialed
+func multipartRequest(client *Client) *HTTPClient {
+	return client.NewRequest().
+		Post("http://127.0.0.1:1/upload").
+		FormData().
+		WithMultipart().
+		AddFileReader("file", "a.txt", "text/plain", strings.NewReader("content")).
+		Push()
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := NewRateLimiter(10, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := limiter.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Two requests of the burst at once, two more at 10 per second
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("elapsed = %s", elapsed)
+	}
+}
+
+func TestRateLimiterWaitExceedsDeadline(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1)
+	if _, err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := limiter.Wait(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatal("wait did not fail at once")
+	}
+}
+
+func TestRateLimitFailureClosesBody(t *testing.T) {
+	limiter := NewRateLimiter(0.1, 1)
+	if _, err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient().SetTimeout(time.Second).SetRateLimiter(limiter)
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		res := multipartRequest(client).Do()
+		if !errors.Is(res.Err(), context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want deadline exceeded", res.Err())
+		}
+	}
+	waitGoroutines(t, before)
+}
@@ -0,0 +1,146 @@
+package grequest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedirectPolicy is called before following every redirect with the next
+// request and the requests made so far, oldest first. Returning
+// http.ErrUseLastResponse stops following and keeps the redirect response,
+// any other error stops following and is reported on the response.
+type RedirectPolicy func(req *http.Request, via []*http.Request) error
+
+var redirectDroppedHeaders = []string{
+	contentType,
+	"Content-Length",
+	"Content-Encoding",
+	"Transfer-Encoding",
+}
+
+var redirectCredentialHeaders = []string{
+	authorization,
+	"Cookie",
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMultipleChoices,
+		http.StatusMovedPermanently,
+		http.StatusFound,
+		http.StatusSeeOther,
+		http.StatusTemporaryRedirect,
+		http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// Follows redirects of the response until the final one
+func (c *HTTPClient) redirectRequest(httpClient *http.Client, req *http.Request, res *http.Response, response *Response) (*http.Response, error) {
+	via := []*http.Request{req}
+	for isRedirect(res.StatusCode) {
+		loc := res.Header.Get("Location")
+		if loc == "" {
+			if res.StatusCode == http.StatusMultipleChoices {
+				return res, nil
+			}
+			return res, ErrInvalidRedirectLocation
+		}
+		if len(via) > c.maxRedirect {
+			return res, ErrTooManyRedirection
+		}
+		target, err := req.URL.Parse(loc)
+		if err != nil {
+			return res, fmt.Errorf("%w: %v", ErrInvalidRedirectLocation, err)
+		}
+		next, err := redirectedRequest(req, res.StatusCode, target)
+		if err != nil {
+			return res, err
+		}
+		if c.redirectPolicy != nil {
+			if err := c.redirectPolicy(next, via); err != nil {
+				if errors.Is(err, http.ErrUseLastResponse) {
+					return res, nil
+				}
+				return res, &RequestError{Phase: PhaseRedirect, Method: next.Method, URL: target.String(), Attempt: response.attempts, Err: err}
+			}
+		}
+
+		if !runVetoHooks(c.hooks.onRedirect, HookEvent{Request: next, Response: res, Attempt: len(via)}) {
+			return res, nil
+		}
+
+		response.redirects = append(response.redirects, Redirect{URL: req.URL, StatusCode: res.StatusCode, Location: target})
+		response.finalURL = target
+		_, _ = io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		res, err = c.fetch(httpClient, next, response)
+		if res == nil {
+			return nil, err
+		}
+		if err != nil {
+			return res, err
+		}
+		req = next
+		via = append(via, next)
+	}
+	return res, nil
+}
+
+// Builds the request for the next hop following RFC 9110 section 15.4
+func redirectedRequest(req *http.Request, status int, target *url.URL) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	next.URL = target
+	next.Host = ""
+
+	if status == http.StatusTemporaryRedirect || status == http.StatusPermanentRedirect {
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return nil, ErrBodyNotRewindable
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			next.Body = body
+		}
+	} else {
+		if req.Method != http.MethodHead {
+			next.Method = http.MethodGet
+		}
+		next.Body = nil
+		next.GetBody = nil
+		next.ContentLength = 0
+		for _, key := range redirectDroppedHeaders {
+			next.Header.Del(key)
+		}
+	}
+
+	downgrade := req.URL.Scheme == "https" && target.Scheme != "https"
+	if downgrade || !strings.EqualFold(req.URL.Host, target.Host) {
+		for _, key := range redirectCredentialHeaders {
+			next.Header.Del(key)
+		}
+	}
+	return next, nil
+}
+
+// Sets default redirect policy for new requests
+func (c *Client) SetRedirectPolicy(policy RedirectPolicy) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.redirectPolicy = policy
+	return c
+}
+
+// Sets the redirect policy of the request
+func (c *HTTPClient) SetRedirectPolicy(policy RedirectPolicy) *HTTPClient {
+	c.redirectPolicy = policy
+	return c
+}
@@ -0,0 +1,196 @@
+package grequest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// Redirects /redirect/{status} to the location query parameter and answers
+// any other path with the method, body and credentials of the request
+var redirectHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if status, ok := strings.CutPrefix(r.URL.Path, "/redirect/"); ok {
+		code, _ := strconv.Atoi(status)
+		w.Header().Set("Location", r.URL.Query().Get("location"))
+		w.WriteHeader(code)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	fmt.Fprintf(w, "%s %s|%s|%s|%s|%s", r.Method, r.URL.RequestURI(), body,
+		r.Header.Get(contentType), r.Header.Get(authorization), r.Header.Get("Cookie"))
+})
+
+func redirectServer() *httptest.Server {
+	return httptest.NewServer(redirectHandler)
+}
+
+func redirectURL(srv *httptest.Server, status int, location string) string {
+	return fmt.Sprintf("%s/redirect/%d?location=%s", srv.URL, status, url.QueryEscape(location))
+}
+
+func TestRedirectMethodAndBody(t *testing.T) {
+	srv := redirectServer()
+	defer srv.Close()
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusMovedPermanently, "GET /echo|||secret|a=1"},
+		{http.StatusFound, "GET /echo|||secret|a=1"},
+		{http.StatusSeeOther, "GET /echo|||secret|a=1"},
+		{http.StatusTemporaryRedirect, "POST /echo|payload|text/plain|secret|a=1"},
+		{http.StatusPermanentRedirect, "POST /echo|payload|text/plain|secret|a=1"},
+	}
+	for _, tt := range tests {
+		res := NewClient().Post(redirectURL(srv, tt.status, "/echo")).
+			Header().Set(contentType, "text/plain").
+			Header().Set(authorization, "secret").
+			Header().Set("Cookie", "a=1").
+			Body().SetString("payload").
+			Do()
+		if err := res.Err(); err != nil {
+			t.Fatalf("%d: %v", tt.status, err)
+		}
+		if got := res.Body().GetStrings(); got != tt.want {
+			t.Errorf("%d: echo = %q, want %q", tt.status, got, tt.want)
+		}
+		if len(res.Redirects()) != 1 || res.Redirects()[0].StatusCode != tt.status {
+			t.Errorf("%d: redirects = %+v", tt.status, res.Redirects())
+		}
+	}
+}
+
+func TestRedirectStripsCredentials(t *testing.T) {
+	other := redirectServer()
+	defer other.Close()
+	srv := redirectServer()
+	defer srv.Close()
+	secure := httptest.NewTLSServer(redirectHandler)
+	defer secure.Close()
+
+	// Another host and a downgrade from https to http
+	for _, from := range []*httptest.Server{srv, secure} {
+		res := NewClient().SetTLSConfig(secure.Client().Transport.(*http.Transport).TLSClientConfig).
+			Get(redirectURL(from, http.StatusFound, other.URL+"/echo")).
+			Header().Set(authorization, "secret").
+			Header().Set("Cookie", "a=1").
+			Do()
+		if err := res.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if got := res.Body().GetStrings(); got != "GET /echo||||" {
+			t.Errorf("redirect from %s: echo = %q, want no credentials", from.URL, got)
+		}
+	}
+}
+
+func TestRedirectedRequestCredentials(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		kept bool
+	}{
+		{"https://h/a", "https://h/b", true},
+		{"http://h/a", "https://h/b", true},
+		{"https://h/a", "https://H/b", true},
+		{"https://h/a", "http://h/b", false},
+		{"https://h/a", "https://other/b", false},
+		{"https://h/a", "https://h:8443/b", false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, tt.from, nil)
+		req.Header.Set(authorization, "secret")
+		req.Header.Set("Cookie", "a=1")
+		target, _ := url.Parse(tt.to)
+		next, err := redirectedRequest(req, http.StatusFound, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kept := next.Header.Get(authorization) != "" && next.Header.Get("Cookie") != ""
+		if kept != tt.kept {
+			t.Errorf("redirect %s -> %s kept credentials = %v, want %v", tt.from, tt.to, kept, tt.kept)
+		}
+	}
+}
+
+func TestRedirectRelativeLocation(t *testing.T) {
+	srv := redirectServer()
+	defer srv.Close()
+	tests := []struct {
+		location string
+		want     string
+	}{
+		{"../files/echo?x=1", "/files/echo?x=1"},
+		{"/echo", "/echo"},
+		{"?location=/echo", "/echo"},
+		{"//" + strings.TrimPrefix(srv.URL, "http://") + "/echo", "/echo"},
+	}
+	for _, tt := range tests {
+		res := NewClient().Get(redirectURL(srv, http.StatusFound, tt.location)).Do()
+		got, _, _ := strings.Cut(res.Body().GetStrings(), "|")
+		if err := res.Err(); err != nil || got != "GET "+tt.want {
+			t.Errorf("Location %q: echo = %q, %v, want GET %s", tt.location, got, err, tt.want)
+		}
+		if final := res.GetCurrentUrl().RequestURI(); final != tt.want {
+			t.Errorf("Location %q: final url = %q, want %q", tt.location, final, tt.want)
+		}
+	}
+}
+
+func TestRedirectPolicyUseLastResponse(t *testing.T) {
+	srv := redirectServer()
+	defer srv.Close()
+	var calls atomic.Int32
+	res := NewClient().Get(redirectURL(srv, http.StatusFound, "/echo")).
+		SetRedirectPolicy(func(req *http.Request, via []*http.Request) error {
+			calls.Add(1)
+			if req.URL.Path != "/echo" || len(via) != 1 {
+				t.Errorf("policy got %s after %d requests", req.URL, len(via))
+			}
+			return http.ErrUseLastResponse
+		}).
+		Do()
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if res.Status().GetCode() != http.StatusFound || len(res.Redirects()) != 0 || calls.Load() != 1 {
+		t.Fatalf("status = %d, redirects = %d, policy calls = %d", res.Status().GetCode(), len(res.Redirects()), calls.Load())
+	}
+
+	stop := errors.New("stop")
+	res = NewClient().Get(redirectURL(srv, http.StatusFound, "/echo")).
+		SetRedirectPolicy(func(*http.Request, []*http.Request) error { return stop }).
+		Do()
+	var reqErr *RequestError
+	if err := res.Err(); !errors.Is(err, stop) || !errors.As(err, &reqErr) || reqErr.Phase != PhaseRedirect {
+		t.Fatalf("err = %v, want the policy error in the redirect phase", err)
+	}
+}
+
+func TestRedirectLimit(t *testing.T) {
+	srv := redirectServer()
+	defer srv.Close()
+	// Every hop is one more redirect wrapped around the last location
+	location := "/echo"
+	for range 3 {
+		location = redirectURL(srv, http.StatusFound, location)
+	}
+	res := NewClient().Get(location).MaxRedirect(3).Do()
+	if err := res.Err(); err != nil || res.Body().GetStrings() != "GET /echo||||" {
+		t.Fatalf("3 redirects with limit 3: %q, %v", res.Body().GetStrings(), err)
+	}
+	res = NewClient().Get(location).MaxRedirect(2).Do()
+	if err := res.Err(); !errors.Is(err, ErrTooManyRedirection) {
+		t.Fatalf("err = %v, want too many redirects", err)
+	}
+	if res.Status().GetCode() != http.StatusFound || len(res.Redirects()) != 2 {
+		t.Fatalf("status = %d, redirects = %d", res.Status().GetCode(), len(res.Redirects()))
+	}
+}
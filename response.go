@@ -0,0 +1,150 @@
+package grequest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Response holds the result of a single call. It does not share state with
+// the builder that produced it, so it stays valid after the builder is reused.
+type Response struct {
+	raw       *http.Response
+	request   *http.Request
+	finalURL  *url.URL
+	redirects []Redirect
+	attempts  int
+	elapsed   time.Duration
+	// Time spent waiting for rate limits
+	rateLimitWait time.Duration
+	fromCache     bool
+	BodyBytes     []byte
+	errs          []error
+	mu            sync.Mutex
+	stream        *streamBody
+	cancel        context.CancelFunc
+}
+
+// streamBody is a response body which is still being read from the network
+type streamBody struct {
+	io.Reader
+	io.Closer
+	consumed bool
+}
+
+// Redirect describes one followed hop of the redirect chain
+type Redirect struct {
+	URL        *url.URL
+	StatusCode int
+	Location   *url.URL
+}
+
+// Gets raw http.Response
+func (r *Response) Raw() *http.Response {
+	return r.raw
+}
+
+// Gets the http.Request that was sent
+func (r *Response) Request() *http.Request {
+	return r.request
+}
+
+// Gets url of the request after all redirects
+func (r *Response) GetCurrentUrl() *url.URL {
+	if r.finalURL == nil {
+		return &url.URL{}
+	}
+	return r.finalURL
+}
+
+// Gets url of the request after all redirects
+func (r *Response) FinalURL() *url.URL {
+	return r.GetCurrentUrl()
+}
+
+// Gets followed redirects in the order they happened
+func (r *Response) Redirects() []Redirect {
+	return r.redirects
+}
+
+// Gets how many times the request was sent, retries included
+func (r *Response) Attempts() int {
+	return r.attempts
+}
+
+// Gets total time spent on the call, retries and redirects included
+func (r *Response) Elapsed() time.Duration {
+	return r.elapsed
+}
+
+// Gets time the request waited for rate limits, part of Elapsed
+func (r *Response) RateLimitWait() time.Duration {
+	return r.rateLimitWait
+}
+
+// Gets whether the body is streamed from the network instead of being buffered
+func (r *Response) IsStream() bool {
+	return r.stream != nil
+}
+
+// Closes the streamed body and releases the connection
+func (r *Response) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeStream()
+}
+
+func (r *Response) closeStream() error {
+	var err error
+	if r.stream != nil && r.stream.Closer != nil {
+		err = r.stream.Close()
+		r.stream.consumed = true
+		r.stream.Closer = nil
+	}
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+	}
+	return err
+}
+
+// Gets whether the response was served by the http cache
+func (r *Response) FromCache() bool {
+	return r.fromCache
+}
+
+// Gets content type fron response headers and return as string
+func (r *Response) ContentType() string {
+	return r.Header().Get(contentType)
+}
+
+func (r *Response) GetErrors() []error {
+	return r.errs
+}
+
+// Gets all errors of the call joined into one, nil when the call succeeded
+func (r *Response) Err() error {
+	return errors.Join(r.errs...)
+}
+
+func (r *Response) addError(phase Phase, err error) *RequestError {
+	e, ok := err.(*RequestError)
+	if !ok {
+		e = r.newError(phase, err)
+	}
+	r.errs = append(r.errs, e)
+	return e
+}
+
+func (r *Response) newError(phase Phase, err error) *RequestError {
+	e := &RequestError{Phase: phase, Attempt: r.attempts, Err: err}
+	if r.request != nil {
+		e.Method = r.request.Method
+		e.URL = r.request.URL.String()
+	}
+	return e
+}
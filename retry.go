@@ -0,0 +1,365 @@
+package grequest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Jitter selects how retry delays are randomized
+type Jitter int
+
+const (
+	// Wait exactly the exponential delay
+	JitterNone Jitter = iota
+	// Wait a random delay between zero and the exponential delay
+	JitterFull
+	// Wait a random delay between the base delay and three times the previous one
+	JitterDecorrelated
+)
+
+const (
+	retryBaseDelayDefault = 200 * time.Millisecond
+	retryMaxDelayDefault  = 10 * time.Second
+	retryAfterField       = "Retry-After"
+)
+
+// RetryPolicy describes when and how often a request is sent again
+type RetryPolicy struct {
+	// Max count of retries, 0 disables retrying
+	MaxRetries int
+	// Status codes which trigger a retry
+	StatusCodes []int
+	// Delay before the first retry, doubled for every next one
+	BaseDelay time.Duration
+	// Upper bound of a single delay
+	MaxDelay time.Duration
+	// Upper bound of the time spent on all attempts, 0 = no limit
+	MaxElapsed time.Duration
+	Jitter     Jitter
+	// Retry on transport errors like connection reset or refused
+	OnNetworkError bool
+}
+
+func defaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		BaseDelay: retryBaseDelayDefault,
+		MaxDelay:  retryMaxDelayDefault,
+		Jitter:    JitterFull,
+	}
+}
+
+func (p RetryPolicy) clone() RetryPolicy {
+	p.StatusCodes = slices.Clone(p.StatusCodes)
+	return p
+}
+
+func (p RetryPolicy) shouldRetry(res *http.Response, err error) bool {
+	if err != nil {
+		return p.OnNetworkError && isRetryableError(err)
+	}
+	return slices.Contains(p.StatusCodes, res.StatusCode)
+}
+
+// Gets delay before the given retry, starting from 1
+func (p RetryPolicy) backoff(retry int, prev time.Duration) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		return 0
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = retryMaxDelayDefault
+	}
+	delay := base
+	for i := 1; i < retry && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	switch p.Jitter {
+	case JitterFull:
+		return rand.N(delay + 1)
+	case JitterDecorrelated:
+		upper := max(prev*3, base)
+		return min(base+rand.N(upper-base+1), maxDelay)
+	}
+	return delay
+}
+
+// Gets delay requested by the server through Retry-After on 429 and 503
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	if res == nil || (res.StatusCode != http.StatusTooManyRequests && res.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	value := res.Header.Get(retryAfterField)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch {
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Waits for the delay unless the context is done first
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Sends the request and retries it according to the retry policy
+func (c *HTTPClient) send(httpClient *http.Client, req *http.Request, response *Response) (*http.Response, error) {
+	policy := c.retry
+	ctx := req.Context()
+	start := time.Now()
+	handler := c.handler(httpClient)
+	var prevDelay time.Duration
+	for retry := 0; ; retry++ {
+		attempt, err := attemptRequest(req, retry == 0)
+		if err != nil {
+			return nil, err
+		}
+		var done func(*http.Response, error)
+		if c.breakers != nil {
+			if done, err = c.breakers.allow(c.breakers.key(attempt)); err != nil {
+				closeUnsent(attempt)
+				return nil, err
+			}
+		}
+		if err := c.waitRateLimit(attempt, response); err != nil {
+			if done != nil {
+				done(nil, nil)
+			}
+			closeUnsent(attempt)
+			return nil, err
+		}
+		response.attempts++
+		runHooks(c.hooks.beforeRequest, HookEvent{Request: attempt, Attempt: retry + 1})
+		res, err := handler(attempt)
+		if done != nil {
+			done(res, err)
+		}
+		if c.adaptive != nil && err == nil {
+			c.adaptive.observe(attempt.URL.Host, res)
+		}
+		if err == nil {
+			runHooks(c.hooks.afterResponse, HookEvent{Request: attempt, Response: res, Attempt: retry + 1})
+		}
+		if policy.MaxRetries <= 0 || !policy.shouldRetry(res, err) {
+			return res, err
+		}
+		if retry < policy.MaxRetries &&
+			!runVetoHooks(c.hooks.onRetry, HookEvent{Request: attempt, Response: res, Attempt: retry + 1, Err: err}) {
+			return res, err
+		}
+		if retry >= policy.MaxRetries {
+			if err != nil {
+				return nil, err
+			}
+			return res, ErrTooManyRetry
+		}
+
+		delay := policy.backoff(retry+1, prevDelay)
+		if after, ok := retryAfter(res); ok {
+			delay = after
+		}
+		prevDelay = delay
+		giveUp := policy.MaxElapsed > 0 && time.Since(start)+delay > policy.MaxElapsed
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			giveUp = true
+		}
+		if giveUp {
+			// Without a retry sent the response is the result as it is
+			if err != nil || retry == 0 {
+				return res, err
+			}
+			return res, fmt.Errorf("%w: next retry in %s exceeds the deadline", ErrTooManyRetry, delay)
+		}
+		if res != nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Gets a copy of the request to send, the copy keeps the original untouched
+// by the cookie jar and gets a fresh body when it is sent again
+func attemptRequest(req *http.Request, first bool) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if first || req.Body == nil || req.Body == http.NoBody {
+		return next, nil
+	}
+	if req.GetBody == nil {
+		return nil, ErrBodyNotRewindable
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	next.Body = body
+	return next, nil
+}
+
+// Closes the body of an attempt which is not sent, a streamed body like a
+// multipart form stops its writer only when closed
+func closeUnsent(req *http.Request) {
+	if req.Body != nil && req.Body != http.NoBody {
+		req.Body.Close()
+	}
+}
+
+// Sets default retry policy for new requests
+func (c *Client) SetRetryPolicy(policy RetryPolicy) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry = policy.clone()
+	return c
+}
+
+// Sets default conditions for retrying a http request
+func (c *Client) RetryIf(statusCodes ...int) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry.StatusCodes = statusCodes
+	if c.retry.MaxRetries == 0 {
+		c.retry.MaxRetries = maxRetriesDefault
+	}
+	return c
+}
+
+// Sets default counts for retrying a http request
+func (c *Client) RetryMax(maxRetries int) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry.MaxRetries = maxRetries
+	return c
+}
+
+// Sets default exponential backoff between retries
+func (c *Client) RetryBackoff(baseDelay, maxDelay time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry.BaseDelay = baseDelay
+	c.retry.MaxDelay = maxDelay
+	return c
+}
+
+// Sets default jitter of the retry delays
+func (c *Client) RetryJitter(jitter Jitter) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry.Jitter = jitter
+	return c
+}
+
+// Sets default max time spent on all attempts of a request
+func (c *Client) RetryMaxElapsed(maxElapsed time.Duration) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry.MaxElapsed = maxElapsed
+	return c
+}
+
+// Enables retrying on transport errors like connection reset by default
+func (c *Client) RetryOnNetworkError() *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry.OnNetworkError = true
+	if c.retry.MaxRetries == 0 {
+		c.retry.MaxRetries = maxRetriesDefault
+	}
+	return c
+}
+
+// Sets retry policy of the request
+func (c *HTTPClient) SetRetryPolicy(policy RetryPolicy) *HTTPClient {
+	c.retry = policy.clone()
+	return c
+}
+
+// Sets the conditions for retrying a http request
+func (c *HTTPClient) RetryIf(statusCodes ...int) *HTTPClient {
+	c.retry.StatusCodes = statusCodes
+	if c.retry.MaxRetries == 0 {
+		c.retry.MaxRetries = maxRetriesDefault
+	}
+	return c
+}
+
+// Sets the counts for retrying a http request
+func (c *HTTPClient) RetryMax(maxRetries int) *HTTPClient {
+	c.retry.MaxRetries = maxRetries
+	return c
+}
+
+// Sets exponential backoff between retries
+func (c *HTTPClient) RetryBackoff(baseDelay, maxDelay time.Duration) *HTTPClient {
+	c.retry.BaseDelay = baseDelay
+	c.retry.MaxDelay = maxDelay
+	return c
+}
+
+// Sets jitter of the retry delays
+func (c *HTTPClient) RetryJitter(jitter Jitter) *HTTPClient {
+	c.retry.Jitter = jitter
+	return c
+}
+
+// Sets max time spent on all attempts of the request
+func (c *HTTPClient) RetryMaxElapsed(maxElapsed time.Duration) *HTTPClient {
+	c.retry.MaxElapsed = maxElapsed
+	return c
+}
+
+// Enables retrying on transport errors like connection reset
+func (c *HTTPClient) RetryOnNetworkError() *HTTPClient {
+	c.retry.OnNetworkError = true
+	if c.retry.MaxRetries == 0 {
+		c.retry.MaxRetries = maxRetriesDefault
+	}
+	return c
+}
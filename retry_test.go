@@ -0,0 +1,115 @@
+package grequest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		status int
+		value  string
+		want   time.Duration
+		ok     bool
+	}{
+		{http.StatusTooManyRequests, "3", 3 * time.Second, true},
+		{http.StatusServiceUnavailable, time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+		{http.StatusTooManyRequests, "soon", 0, false},
+		{http.StatusTooManyRequests, "", 0, false},
+		{http.StatusInternalServerError, "3", 0, false},
+	}
+	for _, tt := range tests {
+		res := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		res.Header.Set(retryAfterField, tt.value)
+		if got, ok := retryAfter(res); got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%d, %q) = %s, %v, want %s, %v", tt.status, tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: JitterNone}
+	for retry, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second} {
+		if got := policy.backoff(retry, 0); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", retry, got, want)
+		}
+	}
+	policy.Jitter = JitterFull
+	for range 100 {
+		if got := policy.backoff(3, 0); got < 0 || got > 400*time.Millisecond {
+			t.Fatalf("full jitter backoff = %s", got)
+		}
+	}
+	policy.Jitter = JitterDecorrelated
+	for range 100 {
+		if got := policy.backoff(3, 300*time.Millisecond); got < policy.BaseDelay || got > 900*time.Millisecond {
+			t.Fatalf("decorrelated backoff = %s", got)
+		}
+	}
+}
+
+func TestRetryStatus(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	res := NewClient().Post(srv.URL).
+		Body().SetString("payload").
+		RetryIf(http.StatusBadGateway).
+		RetryMax(3).
+		RetryBackoff(time.Millisecond, time.Millisecond).
+		Do()
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if res.Attempts() != 3 || res.Body().GetStrings() != "ok" {
+		t.Fatalf("attempts = %d, body = %q", res.Attempts(), res.Body().GetStrings())
+	}
+}
+
+func TestRetryDisabledKeepsStatus(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	requests := map[string]*HTTPClient{
+		"RetryMax(0)":    NewClient().Get(srv.URL).RetryIf(http.StatusServiceUnavailable).RetryMax(0),
+		"SetRetryPolicy": NewClient().Get(srv.URL).SetRetryPolicy(RetryPolicy{StatusCodes: []int{http.StatusServiceUnavailable}}),
+	}
+	for name, req := range requests {
+		hits.Store(0)
+		res := req.Do()
+		if err := res.Err(); err != nil {
+			t.Errorf("%s: err = %v, want the plain 503", name, err)
+		}
+		if res.Status().GetCode() != http.StatusServiceUnavailable || res.Attempts() != 1 || hits.Load() != 1 {
+			t.Errorf("%s: status = %d, attempts = %d, hits = %d", name, res.Status().GetCode(), res.Attempts(), hits.Load())
+		}
+	}
+}
+
+func TestRetryWithoutTimeForRetryKeepsStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(retryAfterField, "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	res := NewClient().Get(srv.URL).RetryIf(http.StatusServiceUnavailable).SetTimeout(time.Second).Do()
+	if err := res.Err(); err != nil || res.Status().GetCode() != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, err = %v, want the plain 503", res.Status().GetCode(), err)
+	}
+}
@@ -0,0 +1,163 @@
+package grequest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// segment is a byte range of a file fetched by its own request
+type segment struct {
+	start int64
+	end   int64
+}
+
+// Downloads the file with parallel range requests, returns nil when the
+// server does not allow it and a single stream has to be used instead
+func (d *Download) toFileSegmented(ctx context.Context, fileName string) *Response {
+	start := time.Now()
+	// Without a context the request timeout bounds the response headers of every request
+	do := (*HTTPClient).DoWithContext
+	if ctx == nil {
+		ctx = context.Background()
+		do = (*HTTPClient).doHeaderTimeout
+	}
+	probe := d.client().clone()
+	probe.request.method = http.MethodHead
+	probe.downloadProgress = nil
+	res := do(probe, ctx)
+	if res.raw == nil || res.raw.StatusCode != http.StatusOK ||
+		!strings.EqualFold(res.raw.Header.Get(acceptRanges), "bytes") {
+		return nil
+	}
+	size := res.raw.ContentLength
+	segments := splitSegments(size, d.segments)
+	if len(segments) < 2 {
+		return nil
+	}
+	validator := (&downloadState{
+		ETag:         res.raw.Header.Get(etagField),
+		LastModified: res.raw.Header.Get(lastModifiedField),
+	}).validator()
+	defer func() {
+		res.elapsed = time.Since(start)
+	}()
+
+	partName := fileName + partSuffix
+	if err := os.MkdirAll(filepath.Dir(fileName), 0777); err != nil {
+		res.addError(PhaseSave, err)
+		return res
+	}
+	file, err := os.Create(partName)
+	if err != nil {
+		res.addError(PhaseSave, err)
+		return res
+	}
+	if err := file.Truncate(size); err != nil {
+		file.Close()
+		res.addError(PhaseSave, err)
+		return res
+	}
+	removeDownloadState(fileName)
+
+	var meter *progressMeter
+	if fn := d.client().downloadProgress; fn != nil {
+		meter = newProgressMeter(fn, d.client().progressInterval, size)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var written int64
+	for _, s := range segments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, segRes := d.fetchSegment(ctx, do, file, s, validator, meter)
+			mu.Lock()
+			defer mu.Unlock()
+			written += n
+			res.attempts += segRes.attempts
+			if err := segRes.Err(); err != nil {
+				res.errs = append(res.errs, segRes.errs...)
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := file.Close(); err != nil {
+		res.addError(PhaseSave, err)
+	}
+	if res.Err() != nil {
+		os.Remove(partName)
+		return res
+	}
+	if info, err := os.Stat(partName); err != nil || written != size || info.Size() != size {
+		os.Remove(partName)
+		res.addError(PhaseRead, fmt.Errorf("%w: got %d of %d bytes", io.ErrUnexpectedEOF, written, size))
+		return res
+	}
+	return d.finish(res, fileName, responseDigests(res.raw))
+}
+
+// Fetches one byte range and writes it at its offset of the file,
+// progress of all segments is reported by the shared meter and do makes the request
+func (d *Download) fetchSegment(ctx context.Context, do func(*HTTPClient, context.Context) *Response, file *os.File, s segment, validator string, meter *progressMeter) (int64, *Response) {
+	req := d.client().clone().Stream()
+	req.downloadProgress = nil
+	req.request.header.Set(rangeField, fmt.Sprintf("bytes=%d-%d", s.start, s.end))
+	if validator != "" {
+		req.request.header.Set(ifRangeField, validator)
+	}
+	res := do(req, ctx)
+	defer res.Close()
+	if res.raw == nil {
+		return 0, res
+	}
+	if res.raw.StatusCode != http.StatusPartialContent {
+		res.addError(PhaseStatus, fmt.Errorf("%w: range request answered with %s", ErrBadStatus, res.raw.Status))
+		return 0, res
+	}
+	if first, _, ok := parseContentRange(res.raw.Header.Get(contentRange)); !ok || first != s.start {
+		res.addError(PhaseStatus, fmt.Errorf("%w: unexpected Content-Range %q", ErrBadStatus, res.raw.Header.Get(contentRange)))
+		return 0, res
+	}
+	length := s.end - s.start + 1
+	var body io.Reader = io.LimitReader(res.Body().get(), length)
+	if meter != nil {
+		body = &progressReader{Reader: body, meter: meter, part: true}
+	}
+	n, err := io.Copy(io.NewOffsetWriter(file, s.start), body)
+	if err != nil {
+		res.addError(copyPhase(err), err)
+		return n, res
+	}
+	if n != length {
+		res.addError(PhaseRead, fmt.Errorf("%w: got %d of %d bytes", io.ErrUnexpectedEOF, n, length))
+	}
+	return n, res
+}
+
+// Splits size bytes into at most count ranges of at least minSegmentSize
+func splitSegments(size int64, count int) []segment {
+	if size <= 0 {
+		return nil
+	}
+	count = int(min(int64(count), max(size/minSegmentSize, 1)))
+	segments := make([]segment, 0, count)
+	chunk := size / int64(count)
+	for i := range count {
+		s := segment{start: int64(i) * chunk, end: int64(i+1)*chunk - 1}
+		if i == count-1 {
+			s.end = size - 1
+		}
+		segments = append(segments, s)
+	}
+	return segments
+}
@@ -0,0 +1,72 @@
+package grequest
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSplitSegments(t *testing.T) {
+	tests := []struct {
+		size  int64
+		count int
+		want  []segment
+	}{
+		{0, 4, nil},
+		{100, 4, []segment{{0, 99}}},
+		{3 * minSegmentSize, 2, []segment{{0, 3*minSegmentSize/2 - 1}, {3 * minSegmentSize / 2, 3*minSegmentSize - 1}}},
+		{2*minSegmentSize + 1, 8, []segment{{0, minSegmentSize - 1}, {minSegmentSize, 2 * minSegmentSize}}},
+	}
+	for _, tt := range tests {
+		if got := splitSegments(tt.size, tt.count); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitSegments(%d, %d) = %v, want %v", tt.size, tt.count, got, tt.want)
+		}
+	}
+}
+
+// Serves content with ranges and an ETag which every response must revalidate
+func rangeServer(content []byte, ranges *atomic.Int32) *httptest.Server {
+	modified := time.Now()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(rangeField) != "" {
+			ranges.Add(1)
+		}
+		w.Header().Set(etagField, `"v1"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeContent(w, r, "file.bin", modified, bytes.NewReader(content))
+	}))
+}
+
+func TestSegmentedDownloadBypassesCache(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 3*minSegmentSize/16)
+	var ranges atomic.Int32
+	srv := rangeServer(content, &ranges)
+	defer srv.Close()
+	client := NewClient().SetCache(NewMemoryCache(10))
+	// Stores the full response with its ETag in the cache
+	if err := client.Get(srv.URL).Do().Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "file.bin")
+	res := client.Get(srv.URL).Download().Segments(3).ToFile(fileName)
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if ranges.Load() != 3 {
+		t.Fatalf("range requests = %d, want 3", ranges.Load())
+	}
+	got, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatal("downloaded file differs from the content")
+	}
+}
@@ -1,25 +1,31 @@
 package grequest
 
 type Status struct {
-	Client *HTTPClient
+	Response *Response
 }
 
 // Init status
-func (c *HTTPClient) Status() *Status {
-	return &Status{Client: c}
+func (r *Response) Status() *Status {
+	return &Status{Response: r}
 }
 
-// Gets http client object
-func (c *Status) client() *HTTPClient {
-	return c.Client
+// Gets http response object
+func (c *Status) response() *Response {
+	return c.Response
 }
 
 // Get status code with string like 200=OK
 func (c *Status) Get() string {
-	return c.client().response().Status
+	if c.response().raw == nil {
+		return ""
+	}
+	return c.response().raw.Status
 }
 
 // Get status code with int
 func (c *Status) GetCode() int {
-	return c.client().response().StatusCode
+	if c.response().raw == nil {
+		return 0
+	}
+	return c.response().raw.StatusCode
 }
@@ -0,0 +1,298 @@
+package grequest
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NestedStyle selects how keys of nested structs and maps are joined
+type NestedStyle int
+
+const (
+	// user[name]=John
+	NestedBrackets NestedStyle = iota
+	// user.name=John
+	NestedDots
+)
+
+// Struct tag read by the encoder, like `form:"name,omitempty"`
+const (
+	formTag   = "form"
+	layoutTag = "layout"
+)
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// valuesEncoder turns structs and maps into url values
+type valuesEncoder struct {
+	nested NestedStyle
+	// Keys encoded from slices, recorded when not nil
+	arrays map[string]bool
+}
+
+// tagOptions are the options after the name in a form tag
+type tagOptions struct {
+	omitempty bool
+	// Time as unix seconds or milliseconds
+	unix      bool
+	unixmilli bool
+	// Bool as 1 or 0
+	int bool
+	// Time layout from the layout tag, RFC 3339 by default
+	layout string
+}
+
+// Encodes url.Values, a map or a struct with form tags into url values.
+// Slices become repeated keys, nested structs and maps use brackets like
+// user[name], nil pointers are skipped and omitempty skips zero values.
+// Time is formatted as RFC 3339 unless the field has a layout tag or the
+// unix or unixmilli option.
+func EncodeValues(data interface{}) (url.Values, error) {
+	return valuesEncoder{}.encode(data)
+}
+
+func (e valuesEncoder) encode(data interface{}) (url.Values, error) {
+	values := url.Values{}
+	switch v := data.(type) {
+	case nil:
+		return values, nil
+	case url.Values:
+		for key, list := range v {
+			values[key] = slices.Clone(list)
+		}
+		return values, nil
+	case map[string]string:
+		for key, value := range v {
+			values.Set(key, value)
+		}
+		return values, nil
+	case map[string][]string:
+		for key, list := range v {
+			values[key] = slices.Clone(list)
+			e.markArray(key)
+		}
+		return values, nil
+	}
+	rv := reflect.ValueOf(data)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values, nil
+		}
+		rv = rv.Elem()
+	}
+	var err error
+	switch rv.Kind() {
+	case reflect.Struct:
+		err = e.encodeStruct(values, "", rv)
+	case reflect.Map:
+		err = e.encodeMap(values, "", rv, tagOptions{})
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnsupportedValue, rv.Type())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (e valuesEncoder) join(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	if e.nested == NestedDots {
+		return prefix + "." + name
+	}
+	return prefix + "[" + name + "]"
+}
+
+func parseFormTag(field reflect.StructField) (string, tagOptions) {
+	name, rest, _ := strings.Cut(field.Tag.Get(formTag), ",")
+	opts := tagOptions{layout: field.Tag.Get(layoutTag)}
+	for _, opt := range strings.Split(rest, ",") {
+		switch opt {
+		case "omitempty":
+			opts.omitempty = true
+		case "unix":
+			opts.unix = true
+		case "unixmilli":
+			opts.unixmilli = true
+		case "int":
+			opts.int = true
+		}
+	}
+	return name, opts
+}
+
+func (e valuesEncoder) encodeStruct(values url.Values, prefix string, rv reflect.Value) error {
+	t := rv.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get(formTag) == "-" {
+			continue
+		}
+		name, opts := parseFormTag(field)
+		fv := rv.Field(i)
+		// Fields of an untagged embedded struct are encoded as its own
+		if field.Anonymous && name == "" {
+			embedded := fv
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded.Type() != timeType {
+				if err := e.encodeStruct(values, prefix, embedded); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if err := e.encodeValue(values, e.join(prefix, name), fv, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e valuesEncoder) encodeMap(values url.Values, prefix string, rv reflect.Value, opts tagOptions) error {
+	if rv.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("%w: %s", ErrUnsupportedValue, rv.Type())
+	}
+	keys := rv.MapKeys()
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	opts.omitempty = false
+	for _, key := range keys {
+		if err := e.encodeValue(values, e.join(prefix, key.String()), rv.MapIndex(key), opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encodes a value under the key, a nil pointer is never encoded while a
+// pointer to a zero value is encoded even with omitempty
+func (e valuesEncoder) encodeValue(values url.Values, key string, rv reflect.Value, opts tagOptions) error {
+	if opts.omitempty && rv.IsZero() {
+		return nil
+	}
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	value, ok, err := formatScalar(rv, opts)
+	if err != nil {
+		return err
+	}
+	if ok {
+		values.Add(key, value)
+		return nil
+	}
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		opts.omitempty = false
+		for i := range rv.Len() {
+			elem := rv.Index(i)
+			elemKey := key
+			if isNested(elem) {
+				elemKey = e.join(key, strconv.Itoa(i))
+			} else {
+				e.markArray(key)
+			}
+			if err := e.encodeValue(values, elemKey, elem, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return e.encodeStruct(values, key, rv)
+	case reflect.Map:
+		return e.encodeMap(values, key, rv, opts)
+	}
+	return fmt.Errorf("%w: %s of %s", ErrUnsupportedValue, rv.Type(), key)
+}
+
+func (e valuesEncoder) markArray(key string) {
+	if e.arrays != nil {
+		e.arrays[key] = true
+	}
+}
+
+// Tells whether the value is encoded under keys of its own
+func isNested(rv reflect.Value) bool {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Type() == timeType || rv.Type().Implements(textMarshalerType) {
+		return false
+	}
+	return rv.Kind() == reflect.Struct || rv.Kind() == reflect.Map
+}
+
+// Formats a single value, false when the value is not a scalar
+func formatScalar(rv reflect.Value, opts tagOptions) (string, bool, error) {
+	if rv.Type() == timeType {
+		t := rv.Interface().(time.Time)
+		switch {
+		case opts.unix:
+			return strconv.FormatInt(t.Unix(), 10), true, nil
+		case opts.unixmilli:
+			return strconv.FormatInt(t.UnixMilli(), 10), true, nil
+		case opts.layout != "":
+			return t.Format(opts.layout), true, nil
+		}
+		return t.Format(time.RFC3339), true, nil
+	}
+	if rv.Type().Implements(textMarshalerType) {
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), true, err
+	}
+	if rv.CanAddr() && rv.Addr().Type().Implements(textMarshalerType) {
+		text, err := rv.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), true, err
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), true, nil
+	case reflect.Bool:
+		if opts.int {
+			if rv.Bool() {
+				return "1", true, nil
+			}
+			return "0", true, nil
+		}
+		return strconv.FormatBool(rv.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), true, nil
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32), true, nil
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), true, nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes()), true, nil
+		}
+	}
+	return "", false, nil
+}
@@ -0,0 +1,123 @@
+package grequest
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type valuesAddress struct {
+	City string `form:"city"`
+	Zip  string `form:"zip,omitempty"`
+}
+
+type ValuesBase struct {
+	ID int `form:"id"`
+}
+
+type valuesUser struct {
+	ValuesBase
+	Name     string            `form:"name"`
+	Nick     string            `form:"nick,omitempty"`
+	Age      *int              `form:"age"`
+	Admin    bool              `form:"admin,int"`
+	Tags     []string          `form:"tag"`
+	Address  valuesAddress     `form:"address"`
+	Contacts []valuesAddress   `form:"contacts"`
+	Meta     map[string]string `form:"meta"`
+	Created  time.Time         `form:"created"`
+	Day      time.Time         `form:"day" layout:"2006-01-02"`
+	Seen     time.Time         `form:"seen,unix"`
+	Secret   string            `form:"-"`
+	Untagged string
+}
+
+func TestEncodeValuesStruct(t *testing.T) {
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	user := valuesUser{
+		ValuesBase: ValuesBase{ID: 7},
+		Name:       "John",
+		Admin:      true,
+		Tags:       []string{"a", "b"},
+		Address:    valuesAddress{City: "Oslo"},
+		Contacts:   []valuesAddress{{City: "Rome", Zip: "00100"}},
+		Meta:       map[string]string{"k": "v"},
+		Created:    created,
+		Day:        created,
+		Seen:       created,
+		Secret:     "hidden",
+		Untagged:   "u",
+	}
+	got, err := EncodeValues(&user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{
+		"id":                {"7"},
+		"name":              {"John"},
+		"admin":             {"1"},
+		"tag":               {"a", "b"},
+		"address[city]":     {"Oslo"},
+		"contacts[0][city]": {"Rome"},
+		"contacts[0][zip]":  {"00100"},
+		"meta[k]":           {"v"},
+		"created":           {"2024-05-06T07:08:09Z"},
+		"day":               {"2024-05-06"},
+		"seen":              {"1714979289"},
+		"Untagged":          {"u"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("EncodeValues =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestEncodeValuesNestedDots(t *testing.T) {
+	got, err := valuesEncoder{nested: NestedDots}.encode(map[string]interface{}{
+		"user": map[string]interface{}{"name": "John", "ids": []int{1, 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := url.Values{"user.name": {"John"}, "user.ids": {"1", "2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("encode = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeValuesPointers(t *testing.T) {
+	zero := 0
+	got, err := EncodeValues(struct {
+		Set   *int `form:"set,omitempty"`
+		Unset *int `form:"unset"`
+	}{Set: &zero})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (url.Values{"set": {"0"}}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("EncodeValues = %v, want %v", got, want)
+	}
+	if values, err := EncodeValues((*valuesUser)(nil)); err != nil || len(values) != 0 {
+		t.Fatalf("nil struct = %v, %v", values, err)
+	}
+}
+
+func TestEncodeValuesMaps(t *testing.T) {
+	got, err := EncodeValues(map[string][]string{"a": {"1", "2"}})
+	if err != nil || !reflect.DeepEqual(got, url.Values{"a": {"1", "2"}}) {
+		t.Fatalf("map of lists = %v, %v", got, err)
+	}
+	got, err = EncodeValues(map[string]string{"a": "1"})
+	if err != nil || !reflect.DeepEqual(got, url.Values{"a": {"1"}}) {
+		t.Fatalf("map = %v, %v", got, err)
+	}
+}
+
+func TestEncodeValuesUnsupported(t *testing.T) {
+	for _, data := range []interface{}{42, map[int]string{1: "a"}, struct{ C chan int }{}} {
+		if _, err := EncodeValues(data); !errors.Is(err, ErrUnsupportedValue) {
+			t.Errorf("EncodeValues(%T) err = %v, want unsupported value", data, err)
+		}
+	}
+}